@@ -1,9 +1,8 @@
 package casing
 
 import (
+	"fmt"
 	"strings"
-
-	"github.com/mgutz/str"
 )
 
 // Casing type
@@ -20,8 +19,96 @@ const (
 	KebabCase      = iota
 	UpperSnakeCase = iota
 	UpperKebabCase = iota
+	DotCase        = iota
+	PathCase       = iota
+	TrainCase      = iota
+	SpaceCase      = iota
+	SentenceCase   = iota
+	// AcronymCamelCase and AcronymTitleCase are camel and title case
+	// with the acronyms in upper case, like userID and APIKey.
+	AcronymCamelCase = iota
+	AcronymTitleCase = iota
+	// Mimic is the casing of matches that are in none of the other
+	// casings, like sPaCe. They are replaced by copying their upper and
+	// lower case letters onto the replacement, see Transfer.
+	Mimic = iota
 )
 
+var casingNames = []string{
+	"original",
+	"lower",
+	"upper",
+	"camel",
+	"title",
+	"snake",
+	"kebab",
+	"upper-snake",
+	"upper-kebab",
+	"dot",
+	"path",
+	"train",
+	"space",
+	"sentence",
+	"acronym-camel",
+	"acronym-title",
+	"mimic",
+}
+
+func (c Casing) String() string {
+	if int(c) < len(casingNames) {
+		return casingNames[c]
+	}
+	return "unknown"
+}
+
+// Parse returns the casing with the specified name.
+func Parse(name string) (Casing, error) {
+	for i, n := range casingNames {
+		if n == name {
+			return Casing(i), nil
+		}
+	}
+	return Original, fmt.Errorf("unknown casing %q, expected one of: %s", name, strings.Join(casingNames, ", "))
+}
+
+// ParseList parses a | separated list of casing names.
+func ParseList(names string) ([]Casing, error) {
+	result := []Casing{}
+	for _, name := range strings.Split(names, "|") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		c, err := Parse(name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// ParseOverrides parses a | separated list of casing=value pairs,
+// like "upper=BRD|title=WorkBoard".
+func ParseOverrides(pairs string) (map[Casing]string, error) {
+	result := map[Casing]string{}
+	for _, pair := range strings.Split(pairs, "|") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("expected casing=value, got %q", pair)
+		}
+		c, err := Parse(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, err
+		}
+		result[c] = parts[1]
+	}
+	return result, nil
+}
+
 // Variants contains variations of a string in different casings.
 type Variants []Variant
 
@@ -31,57 +118,102 @@ type Variant struct {
 	Value  string
 }
 
-// DetermineCasing determines a strings casing
+// DetermineCasing determines a strings casing, picking
+// the most specific of the casings it is in.
 func DetermineCasing(s string) Casing {
-	matches := []Casing{Original}
-	hasUnderscore := strings.Contains(s, "_")
-	hasDash := strings.Contains(s, "-")
-	if s == str.Camelize(s) {
-		matches = append(matches, CamelCase)
-	}
-	if s == strings.ToLower(s) {
-		matches = append(matches, LowerCase)
-	}
-	if s == str.Classify(s) {
-		matches = append(matches, TitleCase)
-	}
-	if s == strings.ToUpper(s) {
-		matches = append(matches, UpperCase)
-	}
-	if s == strings.ToUpper(str.Underscore(s)) && hasUnderscore {
-		matches = append(matches, UpperSnakeCase)
-	}
-	if s == strings.ToUpper(str.Dasherize(s)) && hasDash {
-		matches = append(matches, UpperKebabCase)
-	}
-	if s == str.Underscore(s) && hasUnderscore {
-		matches = append(matches, SnakeCase)
+	return DetermineCasings(s).Specific(s)
+}
+
+// DetermineCasings determines every casing that writes s the way
+// it is written, or just Original when there is none.
+func DetermineCasings(s string) Set {
+	variants := GenerateCasings(s)
+	var result Set
+	for _, v := range variants {
+		if v.Casing != Original && v.Value == s {
+			result = result.Add(v.Casing)
+		}
 	}
-	if s == str.Dasherize(s) && hasDash {
-		matches = append(matches, KebabCase)
+	if result == 0 {
+		return NewSet(Original)
 	}
-
-	// There are going to be multiple matches,
-	// the above is ordered in a way that the
-	// last match is the most specific.
-	return matches[len(matches)-1]
+	return result
 }
 
 // GenerateCasings generates casings for the specified string
 func GenerateCasings(s string) Variants {
-	underscored := strings.Trim(str.Underscore(s), "_")
-	dasherized := strings.Trim(str.Dasherize(s), "-")
+	words := Words(s)
+	lower := lowerWords(words)
+	underscored := strings.Join(lower, "_")
+	dasherized := strings.Join(lower, "-")
 	return Variants{
 		Variant{Original, s},
 		Variant{LowerCase, strings.ToLower(s)},
 		Variant{UpperCase, strings.ToUpper(s)},
-		Variant{CamelCase, str.Camelize(s)},
-		Variant{TitleCase, str.Classify(s)},
+		Variant{CamelCase, camel(words, false)},
+		Variant{TitleCase, title(words, false)},
 		Variant{SnakeCase, underscored},
 		Variant{KebabCase, dasherized},
 		Variant{UpperSnakeCase, strings.ToUpper(underscored)},
-		Variant{UpperKebabCase, strings.ToLower(dasherized)},
+		Variant{UpperKebabCase, strings.ToUpper(dasherized)},
+		Variant{DotCase, strings.Join(lower, ".")},
+		Variant{PathCase, strings.Join(lower, "/")},
+		Variant{TrainCase, train(words)},
+		Variant{SpaceCase, strings.Join(lower, " ")},
+		Variant{SentenceCase, sentence(words)},
+		Variant{AcronymCamelCase, camel(words, true)},
+		Variant{AcronymTitleCase, title(words, true)},
+	}
+}
+
+// Casings returns the casings of the variants written as value.
+func (variants Variants) Casings(value string) Set {
+	var result Set
+	for _, v := range variants {
+		if v.Value == value {
+			result = result.Add(v.Casing)
+		}
+	}
+	return result
+}
+
+// separators are the separators the casings join words with.
+var separators = map[Casing]string{
+	SnakeCase:      "_",
+	UpperSnakeCase: "_",
+	KebabCase:      "-",
+	UpperKebabCase: "-",
+	TrainCase:      "-",
+	DotCase:        ".",
+	PathCase:       "/",
+	SpaceCase:      " ",
+	SentenceCase:   " ",
+}
+
+// separates checks whether s holds the separator of the casing, if it
+// has one. A single word is written the same in snake and lower case,
+// but it is not in snake case.
+func separates(c Casing, s string) bool {
+	separator, ok := separators[c]
+	return !ok || strings.Contains(s, separator)
+}
+
+// Override returns a copy of the variants with the variant in the
+// casing set to value, adding it when there is none.
+func (variants Variants) Override(casing Casing, value string) Variants {
+	result := make(Variants, 0, len(variants)+1)
+	found := false
+	for _, v := range variants {
+		if v.Casing == casing {
+			v.Value = value
+			found = true
+		}
+		result = append(result, v)
+	}
+	if !found {
+		result = append(result, Variant{casing, value})
 	}
+	return result
 }
 
 // GetVariant returns the variant for the specified casing.
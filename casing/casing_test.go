@@ -2,6 +2,7 @@ package casing_test
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/jeffijoe/total-rename/casing"
@@ -15,6 +16,80 @@ func TestDetermineCasing(t *testing.T) {
 	assert.EqualValues(t, casing.TitleCase, casing.DetermineCasing("HelloThere"))
 	assert.EqualValues(t, casing.TitleCase, casing.DetermineCasing("Hello"))
 	assert.EqualValues(t, casing.SnakeCase, casing.DetermineCasing("hello_there"))
+	assert.EqualValues(t, casing.DotCase, casing.DetermineCasing("hello.there"))
+	assert.EqualValues(t, casing.PathCase, casing.DetermineCasing("hello/there"))
+	assert.EqualValues(t, casing.TrainCase, casing.DetermineCasing("Hello-There"))
+	assert.EqualValues(t, casing.SpaceCase, casing.DetermineCasing("hello there"))
+	assert.EqualValues(t, casing.SentenceCase, casing.DetermineCasing("Hello there"))
+	assert.EqualValues(t, casing.UpperKebabCase, casing.DetermineCasing("HELLO-THERE"))
+	assert.EqualValues(t, casing.Original, casing.DetermineCasing("hELLO"))
+}
+
+func TestDetermineCasings(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"space", []string{"snake", "kebab", "dot", "path", "space", "lower", "camel", "acronym-camel"}},
+		{"Space", []string{"train", "sentence", "title", "acronym-title"}},
+		{"SPACE", []string{"upper-snake", "upper-kebab", "upper"}},
+		{"space_name", []string{"snake", "lower"}},
+		{"spaceName", []string{"camel", "acronym-camel"}},
+		{"SPACE_NAME", []string{"upper-snake", "upper"}},
+		{"SPACe", []string{"original"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, casing.DetermineCasings(tt.input).Names())
+		})
+	}
+}
+
+func TestGenerateCasings_Separated(t *testing.T) {
+	variants := casing.GenerateCasings("spaceName")
+	tests := []struct {
+		casing casing.Casing
+		want   string
+	}{
+		{casing.DotCase, "space.name"},
+		{casing.PathCase, "space/name"},
+		{casing.TrainCase, "Space-Name"},
+		{casing.SpaceCase, "space name"},
+		{casing.SentenceCase, "Space name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.casing.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, variants.GetVariant(tt.casing).Value)
+		})
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	overrides, err := casing.ParseOverrides("upper=BRD| title=Work=Board|")
+	assert.NoError(t, err)
+	assert.Equal(t, map[casing.Casing]string{casing.UpperCase: "BRD", casing.TitleCase: "Work=Board"}, overrides)
+
+	_, err = casing.ParseOverrides("upper")
+	assert.Error(t, err)
+	_, err = casing.ParseOverrides("shouty=BRD")
+	assert.Error(t, err)
+}
+
+func TestVariants_Override(t *testing.T) {
+	variants := casing.GenerateCasings("board")
+	overridden := variants.Override(casing.UpperCase, "BRD").Override(casing.Mimic, "bOaRd")
+	assert.Equal(t, "BRD", overridden.GetVariant(casing.UpperCase).Value)
+	assert.Equal(t, "bOaRd", overridden.GetVariant(casing.Mimic).Value)
+	assert.Equal(t, "BOARD", variants.GetVariant(casing.UpperCase).Value, "the variants are copied")
+}
+
+func TestParseList(t *testing.T) {
+	casings, err := casing.ParseList("space| sentence|")
+	assert.NoError(t, err)
+	assert.Equal(t, []casing.Casing{casing.SpaceCase, casing.SentenceCase}, casings)
+
+	_, err = casing.ParseList("spaced")
+	assert.Error(t, err)
 }
 
 func TestGenerateCasings(t *testing.T) {
@@ -63,3 +138,166 @@ func TestVariants_GetVariant(t *testing.T) {
 		})
 	}
 }
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"space", []string{"space"}},
+		{"spaceName", []string{"space", "Name"}},
+		{"SPACE_NAME", []string{"SPACE", "NAME"}},
+		{"Space-Name", []string{"Space", "Name"}},
+		{"space.name/and id", []string{"space", "name", "and", "id"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"userID", []string{"user", "ID"}},
+		{"APIKey", []string{"API", "Key"}},
+		{"v2Space", []string{"v2", "Space"}},
+		{"oauth2Client", []string{"oauth2", "Client"}},
+		{"__init__", []string{"init"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, casing.Words(tt.input))
+		})
+	}
+}
+
+func TestGenerateCasings_Acronyms(t *testing.T) {
+	variants := casing.GenerateCasings("apiKey")
+	assert.Equal(t, "apiKey", variants.GetVariant(casing.CamelCase).Value)
+	assert.Equal(t, "ApiKey", variants.GetVariant(casing.TitleCase).Value)
+	assert.Equal(t, "APIKey", variants.GetVariant(casing.AcronymTitleCase).Value)
+	assert.Equal(t, "api_key", variants.GetVariant(casing.SnakeCase).Value)
+	assert.Equal(t, "API_KEY", variants.GetVariant(casing.UpperSnakeCase).Value)
+
+	variants = casing.GenerateCasings("HTTPServer")
+	assert.Equal(t, "httpServer", variants.GetVariant(casing.CamelCase).Value)
+	assert.Equal(t, "http_server", variants.GetVariant(casing.SnakeCase).Value)
+
+	assert.Equal(t, "userID", casing.GenerateCasings("user id").GetVariant(casing.AcronymCamelCase).Value)
+
+	assert.EqualValues(t, casing.AcronymTitleCase, casing.DetermineCasing("APIKey"))
+	assert.EqualValues(t, casing.AcronymCamelCase, casing.DetermineCasing("userID"))
+	assert.EqualValues(t, casing.TitleCase, casing.DetermineCasing("ApiKey"))
+	assert.EqualValues(t, casing.UpperCase, casing.DetermineCasing("API"))
+}
+
+func TestAddAcronyms(t *testing.T) {
+	assert.False(t, casing.IsAcronym("sku"))
+	casing.AddAcronyms("sku")
+	assert.True(t, casing.IsAcronym("SKU"))
+	assert.Equal(t, "productSKU", casing.GenerateCasings("product sku").GetVariant(casing.AcronymCamelCase).Value)
+}
+
+// TestGenerateCasings_Combinations checks every casing of a multi-word
+// needle and replacement, however they are written. The casing determined
+// for a match must pick the replacement in the same casing, unless the
+// match is written like the needle, which picks the replacement as it is
+// written. Lower and upper case keep the separators the needle is written with.
+func TestGenerateCasings_Combinations(t *testing.T) {
+	needles := []string{"space name", "spaceName", "SpaceName", "space_name", "SPACE_NAME", "space-name", "SPACE-NAME", "Space-Name", "space.name", "Space name"}
+	replacements := []string{"board item", "boardItem", "BoardItem", "board_item", "BOARD_ITEM", "board-item", "BOARD-ITEM", "Board-Item", "board/item", "Board item"}
+	tests := []struct {
+		casing      casing.Casing
+		match       string
+		replacement string
+	}{
+		{casing.CamelCase, "spaceName", "boardItem"},
+		{casing.TitleCase, "SpaceName", "BoardItem"},
+		{casing.SnakeCase, "space_name", "board_item"},
+		{casing.KebabCase, "space-name", "board-item"},
+		{casing.UpperSnakeCase, "SPACE_NAME", "BOARD_ITEM"},
+		{casing.UpperKebabCase, "SPACE-NAME", "BOARD-ITEM"},
+		{casing.DotCase, "space.name", "board.item"},
+		{casing.PathCase, "space/name", "board/item"},
+		{casing.TrainCase, "Space-Name", "Board-Item"},
+		{casing.SpaceCase, "space name", "board item"},
+		{casing.SentenceCase, "Space name", "Board item"},
+		{casing.AcronymCamelCase, "spaceName", "boardItem"},
+		{casing.AcronymTitleCase, "SpaceName", "BoardItem"},
+	}
+	for _, needle := range needles {
+		for _, replacement := range replacements {
+			needleVariants := casing.GenerateCasings(needle)
+			replacementVariants := casing.GenerateCasings(replacement)
+			assert.Equal(t, strings.ToLower(needle), needleVariants.GetVariant(casing.LowerCase).Value)
+			assert.Equal(t, strings.ToUpper(replacement), replacementVariants.GetVariant(casing.UpperCase).Value)
+			for _, tt := range tests {
+				t.Run(needle+"/"+replacement+"/"+tt.casing.String(), func(t *testing.T) {
+					assert.Equal(t, tt.match, needleVariants.GetVariant(tt.casing).Value)
+					assert.Equal(t, tt.replacement, replacementVariants.GetVariant(tt.casing).Value)
+					assert.True(t, needleVariants.Casings(tt.match).Has(tt.casing))
+					picked := needleVariants.Casings(tt.match).Specific(tt.match)
+					want := tt.replacement
+					if tt.match == needle {
+						want = replacement
+					}
+					assert.Equal(t, want, replacementVariants.GetVariant(picked).Value)
+				})
+			}
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	s := casing.NewSet(casing.LowerCase, casing.SnakeCase, casing.CamelCase)
+	assert.True(t, s.Has(casing.SnakeCase))
+	assert.False(t, s.Has(casing.TitleCase))
+	assert.Equal(t, []casing.Casing{casing.SnakeCase, casing.LowerCase, casing.CamelCase}, s.Casings())
+	assert.EqualValues(t, casing.SnakeCase, s.Primary())
+	assert.EqualValues(t, casing.Original, s.Add(casing.Original).Primary())
+	assert.EqualValues(t, casing.Original, casing.Set(0).Primary())
+	assert.EqualValues(t, casing.LowerCase, s.Specific("space"), "a single word is not in snake case")
+	assert.EqualValues(t, casing.SnakeCase, s.Specific("space_name"))
+	assert.EqualValues(t, casing.CamelCase, s.KeepWords(casing.LowerCase))
+	assert.EqualValues(t, casing.TitleCase, s.KeepWords(casing.TitleCase))
+}
+
+func TestGenerateCasings_SingleWord(t *testing.T) {
+	needle := casing.GenerateCasings("space")
+	replacement := casing.GenerateCasings("boardItem")
+	tests := []struct {
+		match string
+		want  string
+	}{
+		{"space", "boardItem"},
+		{"Space", "BoardItem"},
+		{"SPACE", "BOARD_ITEM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.match, func(t *testing.T) {
+			casings := needle.Casings(tt.match)
+			picked := casings.KeepWords(casings.Specific(tt.match))
+			assert.Equal(t, tt.want, replacement.GetVariant(picked).Value)
+		})
+	}
+
+	needle = casing.GenerateCasings("Space")
+	casings := needle.Casings("space")
+	assert.EqualValues(t, casing.LowerCase, casings.Specific("space"))
+	assert.Equal(t, "boardItem", replacement.GetVariant(casings.KeepWords(casing.LowerCase)).Value)
+}
+
+func TestTransfer(t *testing.T) {
+	tests := []struct {
+		match       string
+		replacement string
+		want        string
+	}{
+		{"SPACe", "board", "BOARd"},
+		{"sPaCe", "board", "bOaRd"},
+		{"sPaCe", "boardItem", "boardItem"},
+		{"SPACe", "boardItem", "BOARDItem"},
+		{"Spaces", "boardItem", "BoardItem"},
+		{"SpAcE_nAmE", "board_item", "BoArD_iTeM"},
+		{"SPACE_name", "boardItem", "BOARDitem"},
+		{"ßpace", "board", "board"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.match+"/"+tt.replacement, func(t *testing.T) {
+			assert.Equal(t, tt.want, casing.Transfer(tt.match, tt.replacement))
+		})
+	}
+}
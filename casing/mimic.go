@@ -0,0 +1,49 @@
+package casing
+
+import "unicode"
+
+// Transfer writes replacement in the upper and lower case letters of
+// match. When both have as many characters, the case is copied character
+// by character, so "SPACe" and "board" give "BOARd". Otherwise it is
+// copied word by word: each word of the replacement takes the case of
+// the word of the match at the same position, or of the last one, with
+// the characters past the end of that word following its last character.
+func Transfer(match, replacement string) string {
+	from, to := []rune(match), []rune(replacement)
+	if len(from) == len(to) {
+		return string(copyCase(from, to))
+	}
+	fromSpans, toSpans := WordSpans(from), WordSpans(to)
+	if len(fromSpans) == 0 {
+		return replacement
+	}
+	for i, span := range toSpans {
+		source := fromSpans[len(fromSpans)-1]
+		if i < len(fromSpans) {
+			source = fromSpans[i]
+		}
+		copyCase(from[source[0]:source[1]], to[span[0]:span[1]])
+	}
+	return string(to)
+}
+
+// copyCase changes the case of the letters in to to that of the letters
+// at the same index in from, or the last letter of from past its end.
+func copyCase(from, to []rune) []rune {
+	if len(from) == 0 {
+		return to
+	}
+	for i, r := range to {
+		source := from[len(from)-1]
+		if i < len(from) {
+			source = from[i]
+		}
+		switch {
+		case unicode.IsUpper(source):
+			to[i] = unicode.ToUpper(r)
+		case unicode.IsLower(source):
+			to[i] = unicode.ToLower(r)
+		}
+	}
+	return to
+}
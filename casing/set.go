@@ -0,0 +1,112 @@
+package casing
+
+// Set is a set of casings. A match like "space" is in several casings at
+// once, since lower, camel, snake and kebab case all write it the same.
+type Set uint32
+
+// priority is the order in which the casing of a Set is picked. A match
+// written like the needle keeps the replacement as it is written. The
+// casings that join words with a separator come before lower and upper
+// case, which keep the separators of the replacement, but only decide
+// for a match holding that separator; see Specific. The acronym casings
+// only decide when nothing else does.
+var priority = []Casing{
+	Original,
+	SnakeCase,
+	UpperSnakeCase,
+	KebabCase,
+	UpperKebabCase,
+	TrainCase,
+	DotCase,
+	PathCase,
+	SpaceCase,
+	SentenceCase,
+	LowerCase,
+	UpperCase,
+	CamelCase,
+	TitleCase,
+	AcronymCamelCase,
+	AcronymTitleCase,
+	Mimic,
+}
+
+// wordBreaks maps casings that run words together to a casing a single
+// word is written the same in, but which keeps the words apart.
+var wordBreaks = map[Casing]Casing{
+	LowerCase: CamelCase,
+	UpperCase: UpperSnakeCase,
+}
+
+// NewSet creates a set of the casings.
+func NewSet(casings ...Casing) Set {
+	var result Set
+	for _, c := range casings {
+		result = result.Add(c)
+	}
+	return result
+}
+
+// Add returns the set with the casing added.
+func (s Set) Add(c Casing) Set {
+	return s | 1<<c
+}
+
+// Has checks whether the casing is in the set.
+func (s Set) Has(c Casing) bool {
+	return s&(1<<c) != 0
+}
+
+// Casings returns the casings in the set, in the order they are picked in.
+func (s Set) Casings() []Casing {
+	result := []Casing{}
+	for _, c := range priority {
+		if s.Has(c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// Primary returns the casing the set is picked as, or Original
+// when the set is empty.
+func (s Set) Primary() Casing {
+	for _, c := range priority {
+		if s.Has(c) {
+			return c
+		}
+	}
+	return Original
+}
+
+// Specific returns the casing of the set that the value, written in
+// the casings of the set, is most specifically written in. A single
+// word like "space" is in snake case too, but it is in lower case
+// since it has no underscores.
+func (s Set) Specific(value string) Casing {
+	for _, c := range priority {
+		if s.Has(c) && separates(c, value) {
+			return c
+		}
+	}
+	return s.Primary()
+}
+
+// KeepWords returns the casing of the set that keeps the breaks between
+// words when c is used for a replacement of several words. "SPACE" is
+// in upper case, but replacing it with "BOARDITEM" loses the words that
+// "BOARD_ITEM", in the upper snake case it is in as well, keeps.
+func (s Set) KeepWords(c Casing) Casing {
+	if keeping, ok := wordBreaks[c]; ok && s.Has(keeping) {
+		return keeping
+	}
+	return c
+}
+
+// Names returns the names of the casings in the set.
+func (s Set) Names() []string {
+	result := []string{}
+	for _, c := range s.Casings() {
+		result = append(result, c.String())
+	}
+	return result
+}
@@ -0,0 +1,153 @@
+package casing
+
+import (
+	"strings"
+	"unicode"
+)
+
+// acronyms are the words that are written in upper case in the
+// acronym camel and title casings.
+var acronyms = map[string]bool{}
+
+func init() {
+	AddAcronyms("API", "CSS", "CSV", "DB", "DNS", "HTML", "HTTP", "HTTPS", "ID", "IO", "IP", "JSON", "JWT", "OS", "SQL", "SSH", "TCP", "TLS", "UDP", "UI", "URI", "URL", "UUID", "XML")
+}
+
+// AddAcronyms adds words to the acronyms, so "apiKey" is also searched
+// for and replaced as "APIKey". Case does not matter.
+func AddAcronyms(words ...string) {
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			acronyms[strings.ToUpper(w)] = true
+		}
+	}
+}
+
+// IsAcronym checks whether the word is one of the acronyms.
+func IsAcronym(word string) bool {
+	return acronyms[strings.ToUpper(word)]
+}
+
+// Words splits s into words. Anything but letters and digits separates
+// words, and so do humps: a lower case letter or digit followed by an
+// upper case letter, and the last letter of an upper case run followed
+// by a lower case letter. Digits stay with the word before them, so
+// "HTTPServer" becomes HTTP and Server, and "oauth2Client" becomes
+// oauth2 and Client.
+func Words(s string) []string {
+	runes := []rune(s)
+	result := []string{}
+	for _, span := range WordSpans(runes) {
+		result = append(result, string(runes[span[0]:span[1]]))
+	}
+	return result
+}
+
+// WordSpans returns the start and end rune index of every word in runes,
+// split the way Words splits them.
+func WordSpans(runes []rune) [][2]int {
+	result := [][2]int{}
+	start := -1
+	for i, r := range runes {
+		if !IsWordRune(r) {
+			if start >= 0 {
+				result = append(result, [2]int{start, i})
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && isHump(runes, i) {
+			result = append(result, [2]int{start, i})
+			start = i
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		result = append(result, [2]int{start, len(runes)})
+	}
+	return result
+}
+
+// IsWordRune checks whether r is part of a word, that is, whether it
+// is a letter or digit.
+func IsWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isHump checks whether a new word starts at runes[i].
+func isHump(runes []rune, i int) bool {
+	prev, r := runes[i-1], runes[i]
+	if !unicode.IsUpper(r) {
+		return false
+	}
+	if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+}
+
+// lowerWords returns the words in lower case.
+func lowerWords(words []string) []string {
+	result := make([]string, 0, len(words))
+	for _, w := range words {
+		result = append(result, strings.ToLower(w))
+	}
+	return result
+}
+
+// camel joins the words, each but the first starting with an upper case
+// letter. With acronyms set, the acronyms after the first word are
+// written in upper case.
+func camel(words []string, acronyms bool) string {
+	result := ""
+	for i, w := range lowerWords(words) {
+		switch {
+		case i == 0:
+			result = result + w
+		case acronyms && IsAcronym(w):
+			result = result + strings.ToUpper(w)
+		default:
+			result = result + upperFirst(w)
+		}
+	}
+	return result
+}
+
+// title joins the words, each starting with an upper case letter.
+// With acronyms set, the acronyms are written in upper case.
+func title(words []string, acronyms bool) string {
+	result := ""
+	for _, w := range lowerWords(words) {
+		if acronyms && IsAcronym(w) {
+			result = result + strings.ToUpper(w)
+			continue
+		}
+		result = result + upperFirst(w)
+	}
+	return result
+}
+
+// train joins the words with dashes, each starting with an upper case letter.
+func train(words []string) string {
+	result := make([]string, 0, len(words))
+	for _, w := range lowerWords(words) {
+		result = append(result, upperFirst(w))
+	}
+	return strings.Join(result, "-")
+}
+
+// sentence joins the words with spaces, starting with an upper case letter.
+func sentence(words []string) string {
+	return upperFirst(strings.Join(lowerWords(words), " "))
+}
+
+func upperFirst(s string) string {
+	for i := range s {
+		if i > 0 {
+			return strings.ToUpper(s[:i]) + s[i:]
+		}
+	}
+	return strings.ToUpper(s)
+}
@@ -40,10 +40,13 @@ func (w *Wrapper) Println(args ...interface{}) (int, error) {
 	return fmt.Printf(str)
 }
 
+// stdin is shared by every wrapper, so input that was read ahead
+// of a line is not lost when stdin is not a terminal.
+var stdin = bufio.NewReader(os.Stdin)
+
 // ReadLine does what you expect.
 func (w *Wrapper) ReadLine() (string, error) {
-	scanner := bufio.NewReader(os.Stdin)
-	str, err := scanner.ReadString('\n')
+	str, err := stdin.ReadString('\n')
 	if err != nil {
 		return "", err
 	}
@@ -67,6 +70,7 @@ func (w *Wrapper) Clear() {
 		fmt.Printf("\033[2K")
 		fmt.Print("\033[1A")
 	}
+	fmt.Printf("\033[2K")
 
 	w.NewlineCount = 0
 }
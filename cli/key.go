@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned by ReadKey when Ctrl-C is pressed.
+var ErrInterrupted = errors.New("interrupted")
+
+// ReadKey reads a single key press without waiting for Enter. Enter is
+// returned as '\n'. When stdin is not a terminal, a line is read instead,
+// returning its first character.
+func (w *Wrapper) ReadKey() (rune, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := w.ReadLine()
+		if err != nil && (err != io.EOF || line == "") {
+			return 0, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			return '\n', nil
+		}
+		r, _ := utf8.DecodeRuneInString(line)
+		return r, nil
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, err
+	}
+	defer term.Restore(fd, state)
+	buf := make([]byte, utf8.UTFMax)
+	n, err := os.Stdin.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+	r, _ := utf8.DecodeRune(buf[:n])
+	switch r {
+	case 3:
+		return 0, ErrInterrupted
+	case '\r':
+		return '\n', nil
+	}
+	return r, nil
+}
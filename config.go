@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jeffijoe/total-rename/config"
+	"github.com/jeffijoe/total-rename/util"
+)
+
+// pathOptions are the options that take a file path. Relative paths in
+// the config file are relative to the folder of the config file.
+var pathOptions = map[string]bool{
+	"map":       true,
+	"diff-file": true,
+}
+
+// applyConfig sets the flags that were not passed on the command line
+// from the closest config file and the preset, returning the path of
+// the config file, or an empty string when there is none.
+func applyConfig(preset string) (string, error) {
+	path, err := config.Find(util.GetWD())
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		if preset != "" {
+			return "", fmt.Errorf("--preset %s needs a %s file, but none was found", preset, config.FileName)
+		}
+		return "", nil
+	}
+	c, err := config.Load(path)
+	if err != nil {
+		return "", err
+	}
+	options, err := c.Resolve(preset)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", path, err)
+	}
+	passed := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
+	for _, o := range options {
+		if o.Name == "preset" || o.Name == "help" || flag.Lookup(o.Name) == nil {
+			return "", fmt.Errorf("%s: unknown option %q", path, o.Name)
+		}
+		if passed[o.Name] {
+			continue
+		}
+		value := o.Value
+		if pathOptions[o.Name] && value != "" && !filepath.IsAbs(value) {
+			value = filepath.Join(filepath.Dir(path), value)
+		}
+		if err := flag.Set(o.Name, value); err != nil {
+			return "", fmt.Errorf("%s: %s: %v", path, o.Name, err)
+		}
+	}
+	return path, nil
+}
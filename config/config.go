@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config file.
+const FileName = ".total-rename.yml"
+
+// Option is the value of a command-line flag, without the dashes.
+type Option struct {
+	Name  string
+	Value string
+}
+
+// Options is a list of options in the order they are written in.
+type Options []Option
+
+// Config is a config file. Options apply to every run, and the options
+// of a preset are applied on top of them when it is picked.
+type Config struct {
+	Path    string
+	Options Options
+	Presets map[string]Options
+}
+
+// Find returns the path of the config file in dir or the closest folder
+// above it, or an empty string when there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a config file.
+func Load(filePath string) (*Config, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	c.Path = filePath
+	return c, nil
+}
+
+// Parse reads a config from r. Options are written as flag names
+// mapped to a value; lists are joined with | like the pattern flags expect,
+// and mappings are joined as key=value pairs.
+func Parse(r io.Reader) (*Config, error) {
+	c := &Config{Presets: map[string]Options{}}
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if err == io.EOF {
+			return c, nil
+		}
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return c, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a YAML mapping of options")
+	}
+	for i := 0; i+1 < len(root.Content); i = i + 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "presets" {
+			option, err := parseOption(key, value)
+			if err != nil {
+				return nil, err
+			}
+			c.Options = append(c.Options, option)
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: presets must be a mapping of names to options", value.Line)
+		}
+		for j := 0; j+1 < len(value.Content); j = j + 2 {
+			name, preset := value.Content[j], value.Content[j+1]
+			options, err := parseOptions(preset)
+			if err != nil {
+				return nil, err
+			}
+			c.Presets[name.Value] = options
+		}
+	}
+	return c, nil
+}
+
+func parseOptions(node *yaml.Node) (Options, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping of options", node.Line)
+	}
+	result := Options{}
+	for i := 0; i+1 < len(node.Content); i = i + 2 {
+		option, err := parseOption(node.Content[i], node.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, option)
+	}
+	return result, nil
+}
+
+func parseOption(key, value *yaml.Node) (Option, error) {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		return Option{key.Value, value.Value}, nil
+	case yaml.SequenceNode:
+		items := []string{}
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return Option{}, fmt.Errorf("line %d: items of %q must be strings", item.Line, key.Value)
+			}
+			items = append(items, item.Value)
+		}
+		return Option{key.Value, strings.Join(items, "|")}, nil
+	case yaml.MappingNode:
+		pairs := []string{}
+		for i := 0; i+1 < len(value.Content); i = i + 2 {
+			k, v := value.Content[i], value.Content[i+1]
+			if v.Kind != yaml.ScalarNode {
+				return Option{}, fmt.Errorf("line %d: values of %q must be strings", v.Line, key.Value)
+			}
+			pairs = append(pairs, k.Value+"="+v.Value)
+		}
+		return Option{key.Value, strings.Join(pairs, "|")}, nil
+	}
+	return Option{}, fmt.Errorf("line %d: %q must be a value, a list or a mapping", value.Line, key.Value)
+}
+
+// Resolve returns the options with those of the preset applied on top,
+// or just the options when preset is empty.
+func (c *Config) Resolve(preset string) (Options, error) {
+	result := append(Options{}, c.Options...)
+	if preset == "" {
+		return result, nil
+	}
+	options, ok := c.Presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset %q, expected one of: %s", preset, strings.Join(c.PresetNames(), ", "))
+	}
+	return append(result, options...), nil
+}
+
+// PresetNames returns the names of the presets, sorted.
+func (c *Config) PresetNames() []string {
+	result := []string{}
+	for name := range c.Presets {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,99 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffijoe/total-rename/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const content = `
+ignore:
+  - node_modules
+  - dist
+boundary: true
+context: 5
+presets:
+  web:
+    binary: "*.png|*.svg"
+    context: 10
+`
+
+func TestParse(t *testing.T) {
+	c, err := config.Parse(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, config.Options{
+		{Name: "ignore", Value: "node_modules|dist"},
+		{Name: "boundary", Value: "true"},
+		{Name: "context", Value: "5"},
+	}, c.Options)
+	assert.Equal(t, []string{"web"}, c.PresetNames())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		"- ignore",
+		"ignore:\n  a: [b]\n",
+		"presets:\n  - web\n",
+		"presets:\n  web: true\n",
+	}
+	for _, tt := range tests {
+		_, err := config.Parse(strings.NewReader(tt))
+		assert.Error(t, err, tt)
+	}
+}
+
+func TestParse_Mapping(t *testing.T) {
+	c, err := config.Parse(strings.NewReader("replace-casing:\n  upper: BRD\n  title: WorkBoard\n"))
+	require.NoError(t, err)
+	assert.Equal(t, config.Options{{Name: "replace-casing", Value: "upper=BRD|title=WorkBoard"}}, c.Options)
+}
+
+func TestConfig_Resolve(t *testing.T) {
+	c, err := config.Parse(strings.NewReader(content))
+	require.NoError(t, err)
+
+	options, err := c.Resolve("web")
+	require.NoError(t, err)
+	assert.Equal(t, config.Options{
+		{Name: "ignore", Value: "node_modules|dist"},
+		{Name: "boundary", Value: "true"},
+		{Name: "context", Value: "5"},
+		{Name: "binary", Value: "*.png|*.svg"},
+		{Name: "context", Value: "10"},
+	}, options, "preset options come last so they win")
+
+	options, err = c.Resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, c.Options, options)
+
+	_, err = c.Resolve("mobile")
+	assert.EqualError(t, err, `unknown preset "mobile", expected one of: web`)
+}
+
+func TestFind(t *testing.T) {
+	root, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	nested := filepath.Join(root, "src", "app")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	path, err := config.Find(nested)
+	require.NoError(t, err)
+	assert.NotEqual(t, filepath.Join(root, config.FileName), path)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, config.FileName), []byte(content), 0644))
+	path, err = config.Find(nested)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, config.FileName), path)
+
+	c, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, c.Path)
+	assert.Len(t, c.Options, 3)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/report"
+	"github.com/jeffijoe/total-rename/scanner"
+	"github.com/jeffijoe/total-rename/util"
+)
+
+// printFinds prints the occurences grep-style, ordered by path, followed by
+// the number of occurences per casing and per folder.
+func printFinds(groups scanner.OccurenceGroups) {
+	sorted := append(scanner.OccurenceGroups{}, groups...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Type < sorted[j].Type
+	})
+
+	total := 0
+	casings := map[casing.Casing]int{}
+	folders := map[string]int{}
+	for _, group := range sorted {
+		path := relativePath(group.Path)
+		for _, oc := range group.Occurences {
+			printFind(path, group.Type, oc)
+			total = total + 1
+			casings[oc.Casing] = casings[oc.Casing] + 1
+			folders[filepath.Dir(path)] = folders[filepath.Dir(path)] + 1
+		}
+	}
+	if total == 0 {
+		fmt.Println(report.Summary(groups))
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(report.Summary(groups))
+	fmt.Println()
+	fmt.Println("By casing:")
+	casingOrder := make([]casing.Casing, 0, len(casings))
+	for c := range casings {
+		casingOrder = append(casingOrder, c)
+	}
+	sort.Slice(casingOrder, func(i, j int) bool {
+		a, b := casingOrder[i], casingOrder[j]
+		if casings[a] != casings[b] {
+			return casings[a] > casings[b]
+		}
+		return a < b
+	})
+	for _, c := range casingOrder {
+		fmt.Printf("    %5d  %s\n", casings[c], c)
+	}
+	fmt.Println()
+	fmt.Println("By folder:")
+	folderOrder := make([]string, 0, len(folders))
+	for f := range folders {
+		folderOrder = append(folderOrder, f)
+	}
+	sort.Strings(folderOrder)
+	for _, f := range folderOrder {
+		fmt.Printf("    %5d  %s\n", folders[f], f)
+	}
+}
+
+// printFind prints a single occurence as path:line:column: match [casing].
+// Occurences in paths have no line and column.
+func printFind(path string, groupType scanner.OccurenceGroupType, oc *scanner.Occurence) {
+	if groupType == scanner.OccurenceGroupTypeContent {
+		column := utf8.RuneCountInString(oc.Line[:oc.LineStartIndex]) + 1
+		fmt.Printf("%s:%d:%d: ", path, oc.LineNumber+1, column)
+	} else {
+		fmt.Printf("%s: ", path)
+	}
+	color.Set(color.FgYellow)
+	fmt.Print(oc.Match)
+	color.Unset()
+	fmt.Printf(" [%s]", oc.Casing)
+	if groupType == scanner.OccurenceGroupTypePath {
+		fmt.Print(" in path")
+	}
+	fmt.Println()
+}
+
+// relativePath returns the path relative to the working directory if possible.
+func relativePath(path string) string {
+	if rel, err := filepath.Rel(util.GetWD(), path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,198 @@
+package inflection
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Number is the grammatical number of a word.
+type Number uint8
+
+// Grammatical numbers
+const (
+	Singular = Number(iota)
+	Plural
+)
+
+func (n Number) String() string {
+	if n == Plural {
+		return "plural"
+	}
+	return "singular"
+}
+
+// Rules are the rules used to pluralize words.
+type Rules struct {
+	plurals      []rule
+	irregulars   map[string]string
+	uncountables map[string]struct{}
+}
+
+type rule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// English returns the default English rule set.
+func English() *Rules {
+	r := &Rules{
+		irregulars:   map[string]string{},
+		uncountables: map[string]struct{}{},
+	}
+	// Rules are tried in order, the first one that matches wins.
+	r.addPlurals(
+		"(quiz)$", "${1}zes",
+		"^(ox)$", "${1}en",
+		"([ml])ouse$", "${1}ice",
+		"(matr|vert|ind)(?:ix|ex)$", "${1}ices",
+		"(x|ch|ss|sh)$", "${1}es",
+		"([^aeiouy]|qu)y$", "${1}ies",
+		"(hive)$", "${1}s",
+		"(?:([^f])fe|([lr])f)$", "${1}${2}ves",
+		"sis$", "ses",
+		"([ti])um$", "${1}a",
+		"(buffal|tomat|potat|her)o$", "${1}oes",
+		"(bu)s$", "${1}ses",
+		"(alias|status)$", "${1}es",
+		"(octop|vir)us$", "${1}i",
+		"(ax|test)is$", "${1}es",
+		"s$", "s",
+		"$", "s",
+	)
+	r.AddIrregular("person", "people")
+	r.AddIrregular("man", "men")
+	r.AddIrregular("woman", "women")
+	r.AddIrregular("child", "children")
+	r.AddIrregular("foot", "feet")
+	r.AddIrregular("tooth", "teeth")
+	r.AddIrregular("goose", "geese")
+	r.AddIrregular("criterion", "criteria")
+	r.AddUncountable(
+		"equipment", "information", "rice", "money", "species",
+		"series", "fish", "sheep", "jeans", "police", "news", "metadata",
+	)
+	return r
+}
+
+// AddIrregular adds a singular/plural pair that the rules would get wrong.
+func (r *Rules) AddIrregular(singular, plural string) {
+	r.irregulars[strings.ToLower(singular)] = strings.ToLower(plural)
+}
+
+// AddIrregulars adds irregular pairs from a | separated list
+// of singular:plural pairs, e.g. "person:people|child:children".
+func (r *Rules) AddIrregulars(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(pattern, "|") {
+		parts := strings.Split(pair, ":")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid irregular pair %q, expected <singular>:<plural>", pair)
+		}
+		r.AddIrregular(parts[0], parts[1])
+	}
+	return nil
+}
+
+// AddUncountable adds words that are the same in singular and plural.
+func (r *Rules) AddUncountable(words ...string) {
+	for _, w := range words {
+		r.uncountables[strings.ToLower(w)] = struct{}{}
+	}
+}
+
+// Pluralize returns the plural form of s. Only the last word of
+// s is inflected, and it keeps its casing, so "spaceMember"
+// becomes "spaceMembers" and "SPACE_CATEGORY" becomes "SPACE_CATEGORIES".
+func (r *Rules) Pluralize(s string) string {
+	head, word, tail := splitLastWord(s)
+	if word == "" {
+		return s
+	}
+	lower := strings.ToLower(word)
+	if _, ok := r.uncountables[lower]; ok {
+		return s
+	}
+	return head + matchCase(word, r.pluralizeLower(lower)) + tail
+}
+
+func (r *Rules) pluralizeLower(word string) string {
+	// The longest matching irregular wins, so the result does not
+	// depend on map iteration order.
+	matched := ""
+	for singular := range r.irregulars {
+		if len(singular) > len(matched) && isIrregularMatch(word, singular) {
+			matched = singular
+		}
+	}
+	if matched != "" {
+		return word[:len(word)-len(matched)] + r.irregulars[matched]
+	}
+	for _, rl := range r.plurals {
+		if rl.pattern.MatchString(word) {
+			return rl.pattern.ReplaceAllString(word, rl.replacement)
+		}
+	}
+	return word
+}
+
+func (r *Rules) addPlurals(patternsAndReplacements ...string) {
+	for i := 0; i+1 < len(patternsAndReplacements); i = i + 2 {
+		r.plurals = append(r.plurals, rule{
+			pattern:     regexp.MustCompile(patternsAndReplacements[i]),
+			replacement: patternsAndReplacements[i+1],
+		})
+	}
+}
+
+// isIrregularMatch checks whether the word ends with the irregular singular.
+// Short singulars must be the entire word so they don't hit unrelated words
+// ("salesperson" should match "person", "human" should not match "man").
+func isIrregularMatch(word, singular string) bool {
+	if word == singular {
+		return true
+	}
+	return len(singular) > 3 && strings.HasSuffix(word, singular)
+}
+
+// splitLastWord splits s into everything before its last word, the last
+// word, and any non-letters trailing it.
+func splitLastWord(s string) (string, string, string) {
+	runes := []rune(s)
+	end := len(runes)
+	for end > 0 && !unicode.IsLetter(runes[end-1]) {
+		end = end - 1
+	}
+	if end == 0 {
+		return s, "", ""
+	}
+	start := end - 1
+	allUpper := unicode.IsUpper(runes[start])
+	for start > 0 && unicode.IsLetter(runes[start-1]) {
+		if !allUpper && unicode.IsUpper(runes[start]) {
+			break
+		}
+		if allUpper && !unicode.IsUpper(runes[start-1]) {
+			break
+		}
+		start = start - 1
+	}
+	return string(runes[:start]), string(runes[start:end]), string(runes[end:])
+}
+
+// matchCase applies the casing of word to the inflected lowercase word.
+func matchCase(word, inflected string) string {
+	runes := []rune(word)
+	if len(runes) > 1 && word == strings.ToUpper(word) {
+		return strings.ToUpper(inflected)
+	}
+	if unicode.IsUpper(runes[0]) {
+		result := []rune(inflected)
+		result[0] = unicode.ToUpper(result[0])
+		return string(result)
+	}
+	return inflected
+}
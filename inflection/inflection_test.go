@@ -0,0 +1,55 @@
+package inflection_test
+
+import (
+	"testing"
+
+	"github.com/jeffijoe/total-rename/inflection"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_Pluralize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"space", "spaces"},
+		{"Space", "Spaces"},
+		{"SPACE", "SPACES"},
+		{"category", "categories"},
+		{"box", "boxes"},
+		{"status", "statuses"},
+		{"analysis", "analyses"},
+		{"person", "people"},
+		{"Person", "People"},
+		{"salesperson", "salespeople"},
+		{"human", "humans"},
+		{"man", "men"},
+		{"sheep", "sheep"},
+		{"spaceMember", "spaceMembers"},
+		{"spaceCategory", "spaceCategories"},
+		{"space_person", "space_people"},
+		{"SPACE_CATEGORY", "SPACE_CATEGORIES"},
+		{"space-child", "space-children"},
+		{"SpaceMember", "SpaceMembers"},
+		{"space2", "spaces2"},
+		{"42", "42"},
+	}
+	rules := inflection.English()
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, rules.Pluralize(tt.input))
+		})
+	}
+}
+
+func TestRules_AddIrregulars(t *testing.T) {
+	rules := inflection.English()
+	assert.NoError(t, rules.AddIrregulars("cactus:cacti|Formula:Formulae"))
+	assert.Equal(t, "cacti", rules.Pluralize("cactus"))
+	assert.Equal(t, "spaceFormulae", rules.Pluralize("spaceFormula"))
+	assert.Equal(t, "FORMULAE", rules.Pluralize("FORMULA"))
+
+	assert.Error(t, rules.AddIrregulars("cactus"))
+	assert.Error(t, rules.AddIrregulars("cactus:"))
+	assert.NoError(t, rules.AddIrregulars(""))
+}
@@ -0,0 +1,390 @@
+package journal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jeffijoe/total-rename/util"
+)
+
+// Version is the version of the journal file format. Version 1 journals
+// hold their entries in the journal object, version 2 journals write
+// each entry on a line of its own after it.
+const Version = 2
+
+// Operation is the kind of change a journal entry records.
+type Operation string
+
+// Journaled operations
+const (
+	OperationRename  = Operation("rename")
+	OperationReplace = Operation("replace")
+)
+
+// Journal is a record of the changes made by a run, in the order they were
+// made, so they can be undone.
+type Journal struct {
+	Version    int       `json:"version"`
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	WorkingDir string    `json:"workingDir"`
+	Args       []string  `json:"args"`
+	Entries    []*Entry  `json:"entries,omitempty"`
+
+	// dir is where the journal is saved to after every change,
+	// see Autosave.
+	dir string
+}
+
+// Entry is a single change. Renames have OldPath and NewPath, content
+// replacements have Path, the Original contents and the hash of the
+// new contents.
+type Entry struct {
+	Operation Operation `json:"operation"`
+	OldPath   string    `json:"oldPath,omitempty"`
+	NewPath   string    `json:"newPath,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Original  []byte    `json:"original,omitempty"`
+	NewHash   string    `json:"newHash,omitempty"`
+}
+
+// ChangedError is returned when files have changed since the
+// journal was written, so undoing would lose those changes.
+type ChangedError struct {
+	Paths []string
+}
+
+func (e *ChangedError) Error() string {
+	return fmt.Sprintf("%d files changed since the journal was written", len(e.Paths))
+}
+
+// New creates an empty journal for a run with the specified arguments.
+func New(args []string) *Journal {
+	now := time.Now().UTC()
+	return &Journal{
+		Version:    Version,
+		ID:         now.Format("20060102-150405.000000000"),
+		CreatedAt:  now,
+		WorkingDir: util.GetWD(),
+		Args:       args,
+		Entries:    []*Entry{},
+	}
+}
+
+// Dir returns the folder journals are stored in.
+func Dir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "total-rename", "journals"), nil
+}
+
+// Load reads a journal file.
+func Load(filePath string) (*Journal, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	j := &Journal{}
+	if err := dec.Decode(j); err != nil {
+		return nil, fmt.Errorf("invalid journal %s: %v", filePath, err)
+	}
+	if j.Version < 1 || j.Version > Version {
+		return nil, fmt.Errorf("journal %s has version %d, expected 1 to %d", filePath, j.Version, Version)
+	}
+	if j.Entries == nil {
+		j.Entries = []*Entry{}
+	}
+	for {
+		e := &Entry{}
+		err := dec.Decode(e)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// A run that was cut short can leave half an entry.
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid journal %s: %v", filePath, err)
+		}
+		j.Entries = append(j.Entries, e)
+	}
+	return j, nil
+}
+
+// List returns the paths of the journals in dir, oldest first.
+func List(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	result := []string{}
+	for _, fi := range files {
+		if !fi.IsDir() && filepath.Ext(fi.Name()) == ".json" {
+			result = append(result, filepath.Join(dir, fi.Name()))
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// Prune removes all but the newest keep journals in dir.
+func Prune(dir string, keep int) error {
+	paths, err := List(dir)
+	if err != nil {
+		return err
+	}
+	for len(paths) > keep {
+		if err := os.Remove(paths[0]); err != nil {
+			return err
+		}
+		paths = paths[1:]
+	}
+	return nil
+}
+
+// Save writes the journal to dir, and returns the path it was written to.
+func (j *Journal) Save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	header := *j
+	header.Entries = nil
+	content, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	content = append(content, '\n')
+	for _, e := range j.Entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return "", err
+		}
+		content = append(append(content, line...), '\n')
+	}
+	filePath := j.path(dir)
+	return filePath, ioutil.WriteFile(filePath, content, 0600)
+}
+
+// Autosave saves the journal to dir, and keeps it up to date as changes
+// are recorded, so the changes made by a run that is cut short can still
+// be undone.
+func (j *Journal) Autosave(dir string) error {
+	if _, err := j.Save(dir); err != nil {
+		return err
+	}
+	j.dir = dir
+	return nil
+}
+
+// Remove removes the journal from dir, if it was saved there.
+func (j *Journal) Remove(dir string) error {
+	if err := os.Remove(j.path(dir)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (j *Journal) path(dir string) string {
+	return filepath.Join(dir, j.ID+".json")
+}
+
+// record adds the entry, appending it to the autosaved journal. Errors
+// are left for the Save at the end of the run to report.
+func (j *Journal) record(e *Entry) {
+	j.Entries = append(j.Entries, e)
+	if j.dir == "" {
+		return
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(j.path(j.dir), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// forget removes the entry at index i, rewriting the autosaved journal.
+func (j *Journal) forget(i int) {
+	j.Entries = append(j.Entries[:i], j.Entries[i+1:]...)
+	if j.dir != "" {
+		j.Save(j.dir)
+	}
+}
+
+// Rename wraps rename so successful renames are recorded.
+func (j *Journal) Rename(rename func(oldPath, newPath string) error) func(oldPath, newPath string) error {
+	return func(oldPath, newPath string) error {
+		if err := rename(oldPath, newPath); err != nil {
+			return err
+		}
+		j.record(&Entry{
+			Operation: OperationRename,
+			OldPath:   oldPath,
+			NewPath:   newPath,
+		})
+		return nil
+	}
+}
+
+// ReplaceFile wraps replaceFile so successful content replacements
+// are recorded, along with the original contents.
+func (j *Journal) ReplaceFile(replaceFile func(filePath, newContent string) error) func(filePath, newContent string) error {
+	return func(filePath, newContent string) error {
+		original, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		if err := replaceFile(filePath, newContent); err != nil {
+			return err
+		}
+		j.record(&Entry{
+			Operation: OperationReplace,
+			Path:      filePath,
+			Original:  original,
+			NewHash:   hash([]byte(newContent)),
+		})
+		return nil
+	}
+}
+
+// RenameBack wraps rename for renames that revert an earlier one, like
+// when rolling back. The entry of the reverted rename is removed, so undo
+// does not try to revert it again.
+func (j *Journal) RenameBack(rename func(oldPath, newPath string) error) func(oldPath, newPath string) error {
+	record := j.Rename(rename)
+	return func(oldPath, newPath string) error {
+		for i := len(j.Entries) - 1; i >= 0; i-- {
+			e := j.Entries[i]
+			if e.Operation == OperationRename && e.OldPath == newPath && e.NewPath == oldPath {
+				if err := rename(oldPath, newPath); err != nil {
+					return err
+				}
+				j.forget(i)
+				return nil
+			}
+		}
+		return record(oldPath, newPath)
+	}
+}
+
+// ReplaceFileBack wraps replaceFile for replacements that restore the
+// original contents of an earlier one, like when rolling back. The entry
+// of the reverted replacement is removed, so undo does not try to
+// revert it again.
+func (j *Journal) ReplaceFileBack(replaceFile func(filePath, newContent string) error) func(filePath, newContent string) error {
+	record := j.ReplaceFile(replaceFile)
+	return func(filePath, newContent string) error {
+		for i := len(j.Entries) - 1; i >= 0; i-- {
+			e := j.Entries[i]
+			if e.Operation == OperationReplace && e.Path == filePath && string(e.Original) == newContent {
+				if err := replaceFile(filePath, newContent); err != nil {
+					return err
+				}
+				j.forget(i)
+				return nil
+			}
+		}
+		return record(filePath, newContent)
+	}
+}
+
+// Verify checks that every recorded change is still in place, returning
+// a *ChangedError listing the files that have been changed or removed since.
+func (j *Journal) Verify() error {
+	changed := []string{}
+	for i, e := range j.Entries {
+		current := j.currentPath(i)
+		switch e.Operation {
+		case OperationRename:
+			if _, err := os.Lstat(current); err != nil {
+				changed = append(changed, current)
+			}
+		case OperationReplace:
+			content, err := ioutil.ReadFile(current)
+			if err != nil || hash(content) != e.NewHash {
+				changed = append(changed, current)
+			}
+		}
+	}
+	if len(changed) > 0 {
+		return &ChangedError{Paths: changed}
+	}
+	return nil
+}
+
+// Undo reverts the recorded changes, newest first. Undone entries are
+// removed from the journal, so when undoing fails halfway, the journal
+// holds what is left to undo.
+func (j *Journal) Undo(rename func(oldPath, newPath string) error, replaceFile func(filePath, newContent string) error) error {
+	if err := j.Verify(); err != nil {
+		return err
+	}
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		e := j.Entries[i]
+		switch e.Operation {
+		case OperationRename:
+			if err := undoRename(e, rename); err != nil {
+				return err
+			}
+		case OperationReplace:
+			if err := replaceFile(e.Path, string(e.Original)); err != nil {
+				return err
+			}
+		}
+		j.Entries = j.Entries[:i]
+	}
+	return nil
+}
+
+func undoRename(e *Entry, rename func(oldPath, newPath string) error) error {
+	// Renames that only change the casing find the file itself
+	// on case-insensitive file systems.
+	if existing, err := os.Lstat(e.OldPath); err == nil {
+		renamed, err := os.Lstat(e.NewPath)
+		if err != nil || !os.SameFile(existing, renamed) {
+			return fmt.Errorf("can not rename %s back to %s, it already exists", e.NewPath, e.OldPath)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(e.OldPath), 0755); err != nil {
+		return err
+	}
+	return rename(e.NewPath, e.OldPath)
+}
+
+// currentPath returns where the file of the entry at index i is now,
+// following the renames made after it.
+func (j *Journal) currentPath(i int) string {
+	e := j.Entries[i]
+	result := e.Path
+	if e.Operation == OperationRename {
+		result = e.NewPath
+	}
+	for _, later := range j.Entries[i+1:] {
+		if later.Operation == OperationRename {
+			result, _ = util.RebasePath(result, later.OldPath, later.NewPath)
+		}
+	}
+	return result
+}
+
+func hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,166 @@
+package journal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(path, content string) error {
+	return ioutil.WriteFile(path, []byte(content), 0644)
+}
+
+// setup creates a folder with a "space" folder holding a "space.js" file,
+// then renames both to "board" and replaces the file contents, recording
+// the changes in the returned journal.
+func setup(t *testing.T) (string, *Journal) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "space"), 0755))
+	require.NoError(t, writeFile(filepath.Join(tempDir, "space", "space.js"), "space\xff"))
+
+	j := New([]string{"**/*", "space", "board"})
+	rename := j.Rename(os.Rename)
+	replace := j.ReplaceFile(writeFile)
+	require.NoError(t, replace(filepath.Join(tempDir, "space", "space.js"), "board\xff"))
+	require.NoError(t, rename(filepath.Join(tempDir, "space", "space.js"), filepath.Join(tempDir, "space", "board.js")))
+	require.NoError(t, rename(filepath.Join(tempDir, "space"), filepath.Join(tempDir, "board")))
+	return tempDir, j
+}
+
+func TestJournal_Undo(t *testing.T) {
+	tempDir, j := setup(t)
+	defer os.RemoveAll(tempDir)
+
+	dir := filepath.Join(tempDir, "journals")
+	saved, err := j.Save(dir)
+	require.NoError(t, err)
+	loaded, err := Load(saved)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(loaded.Entries))
+
+	require.NoError(t, loaded.Undo(os.Rename, writeFile))
+	assert.Equal(t, 0, len(loaded.Entries))
+	content, err := ioutil.ReadFile(filepath.Join(tempDir, "space", "space.js"))
+	require.NoError(t, err)
+	assert.Equal(t, "space\xff", string(content))
+	_, err = os.Stat(filepath.Join(tempDir, "board"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestJournal_UndoChanged(t *testing.T) {
+	tempDir, j := setup(t)
+	defer os.RemoveAll(tempDir)
+
+	changed := filepath.Join(tempDir, "board", "board.js")
+	require.NoError(t, writeFile(changed, "board, edited"))
+	err := j.Undo(os.Rename, writeFile)
+	require.IsType(t, &ChangedError{}, err)
+	assert.Equal(t, []string{changed}, err.(*ChangedError).Paths)
+	assert.Equal(t, 3, len(j.Entries))
+	_, err = os.Stat(filepath.Join(tempDir, "board"))
+	assert.NoError(t, err, "nothing should be undone")
+}
+
+func TestJournal_UndoRemoved(t *testing.T) {
+	tempDir, j := setup(t)
+	defer os.RemoveAll(tempDir)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(tempDir, "board")))
+	err := j.Verify()
+	require.IsType(t, &ChangedError{}, err)
+	assert.Equal(t, 3, len(err.(*ChangedError).Paths))
+}
+
+func TestList(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	paths, err := List(filepath.Join(tempDir, "missing"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, paths)
+
+	for _, id := range []string{"20200102-000000.000000000", "20200101-000000.000000000"} {
+		j := New(nil)
+		j.ID = id
+		_, err := j.Save(tempDir)
+		require.NoError(t, err)
+	}
+	paths, err = List(tempDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tempDir, "20200101-000000.000000000.json"),
+		filepath.Join(tempDir, "20200102-000000.000000000.json"),
+	}, paths)
+}
+
+func TestJournal_Autosave(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	require.NoError(t, writeFile(filepath.Join(tempDir, "space.js"), "space"))
+
+	dir := filepath.Join(tempDir, "journals")
+	j := New(nil)
+	require.NoError(t, j.Autosave(dir))
+	loaded, err := Load(filepath.Join(dir, j.ID+".json"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(loaded.Entries), "saved before the first change")
+
+	require.NoError(t, j.ReplaceFile(writeFile)(filepath.Join(tempDir, "space.js"), "board"))
+	require.NoError(t, j.Rename(os.Rename)(filepath.Join(tempDir, "space.js"), filepath.Join(tempDir, "board.js")))
+	loaded, err = Load(filepath.Join(dir, j.ID+".json"))
+	require.NoError(t, err)
+	assert.Equal(t, j.Entries, loaded.Entries, "saved after every change")
+
+	require.NoError(t, j.RenameBack(os.Rename)(filepath.Join(tempDir, "board.js"), filepath.Join(tempDir, "space.js")))
+	loaded, err = Load(filepath.Join(dir, j.ID+".json"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(loaded.Entries), "the reverted rename is removed")
+
+	require.NoError(t, j.Remove(dir))
+	paths, err := List(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, paths)
+}
+
+func TestLoad_Version1(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "20200101-000000.000000000.json")
+	require.NoError(t, writeFile(filePath, `{
+  "version": 1,
+  "id": "20200101-000000.000000000",
+  "entries": [{"operation": "rename", "oldPath": "/space", "newPath": "/board"}]
+}`))
+	j, err := Load(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, []*Entry{{Operation: OperationRename, OldPath: "/space", NewPath: "/board"}}, j.Entries)
+}
+
+func TestPrune(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	for _, id := range []string{"20200103-000000.000000000", "20200101-000000.000000000", "20200102-000000.000000000"} {
+		j := New(nil)
+		j.ID = id
+		_, err := j.Save(tempDir)
+		require.NoError(t, err)
+	}
+	require.NoError(t, Prune(tempDir, 2))
+	paths, err := List(tempDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tempDir, "20200102-000000.000000000.json"),
+		filepath.Join(tempDir, "20200103-000000.000000000.json"),
+	}, paths)
+}
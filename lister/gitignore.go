@@ -0,0 +1,186 @@
+package lister
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jeffijoe/total-rename/simplematch"
+)
+
+// vcsIgnore decides whether paths are ignored by the .gitignore files of
+// the repository they are in, as well as .git/info/exclude.
+type vcsIgnore struct {
+	// top is the root of the repository, or the scan root when
+	// not in a repository. Paths outside of it are never ignored.
+	top     string
+	rules   map[string][]*ignoreRule
+	results map[string]bool
+}
+
+// ignoreRule is a single line from an ignore file.
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// newVCSIgnore creates a vcsIgnore for the repository that scanRoot is in.
+func newVCSIgnore(scanRoot string) *vcsIgnore {
+	top := findRepositoryRoot(scanRoot)
+	v := &vcsIgnore{
+		top:     top,
+		rules:   map[string][]*ignoreRule{},
+		results: map[string]bool{},
+	}
+	if top == "" {
+		v.top = scanRoot
+		return v
+	}
+	exclude := readIgnoreFile(filepath.Join(top, ".git", "info", "exclude"))
+	v.rules[top] = append(exclude, readIgnoreFile(filepath.Join(top, ".gitignore"))...)
+	return v
+}
+
+// findRepositoryRoot walks up from dir until it finds a directory containing .git.
+func findRepositoryRoot(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Ignored determines whether the path is ignored, either by a rule
+// matching it or by a rule matching one of its parent directories.
+func (v *vcsIgnore) Ignored(path string, isDir bool) bool {
+	rel, err := filepath.Rel(v.top, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	current := v.top
+	for i, segment := range segments {
+		current = filepath.Join(current, segment)
+		segmentIsDir := isDir || i < len(segments)-1
+		if v.ignoredSelf(current, segmentIsDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredSelf checks the rules of every directory from the top down to
+// the parent of path. The last matching rule wins.
+func (v *vcsIgnore) ignoredSelf(path string, isDir bool) bool {
+	key := path
+	if isDir {
+		key = key + string(os.PathSeparator)
+	}
+	if result, ok := v.results[key]; ok {
+		return result
+	}
+	if isDir && filepath.Base(path) == ".git" {
+		v.results[key] = true
+		return true
+	}
+	ignored := false
+	dir := v.top
+	rel, _ := filepath.Rel(v.top, path)
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range segments {
+		dirRel := strings.Join(segments[i:], "/")
+		for _, rule := range v.rulesFor(dir) {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.pattern.MatchString(dirRel) {
+				ignored = !rule.negate
+			}
+		}
+		dir = filepath.Join(dir, segments[i])
+	}
+	v.results[key] = ignored
+	return ignored
+}
+
+func (v *vcsIgnore) rulesFor(dir string) []*ignoreRule {
+	if rules, ok := v.rules[dir]; ok {
+		return rules
+	}
+	rules := readIgnoreFile(filepath.Join(dir, ".gitignore"))
+	v.rules[dir] = rules
+	return rules
+}
+
+// readIgnoreFile reads the rules of an ignore file. A missing or
+// unreadable file has no rules.
+func readIgnoreFile(filePath string) []*ignoreRule {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	result := []*ignoreRule{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if rule := parseIgnoreRule(s.Text()); rule != nil {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+// parseIgnoreRule parses a line of an ignore file using gitignore semantics.
+// Returns nil for blank lines, comments and invalid patterns.
+func parseIgnoreRule(line string) *ignoreRule {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimUnescapedTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	rule := &ignoreRule{}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	// A pattern with a slash anywhere but the end is relative to the
+	// directory of the ignore file, otherwise it matches at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	expr := simplematch.GlobToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	pattern, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil
+	}
+	rule.pattern = pattern
+	return rule
+}
+
+func trimUnescapedTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
@@ -32,33 +32,35 @@ type FileNode struct {
 }
 
 // ListFileNodes lists file nodes relative from root matching the specified glob.
-func ListFileNodes(root, glob, ignorePattern string) (FileNodes, error) {
+// The ignore pattern is anchored to the scan root, see ScanRoot.
+// When useVCSIgnore is set, nodes ignored by .gitignore files or .git/info/exclude
+// are skipped as well.
+func ListFileNodes(root, glob, ignorePattern string, useVCSIgnore bool) (FileNodes, error) {
 	root = filepath.Clean(filepath.FromSlash(root))
 	empty := FileNodes{}
-	var path string
-	var err error
-	ignore := simplematch.NewMatcher(ignorePattern)
-	if filepath.IsAbs(glob) {
-		//path = filepath.FromSlash(glob)
-		path = glob
-	} else {
-		if strings.HasPrefix(glob, "~") {
-			user, err := user.Current()
-			if err != nil {
-				return empty, err
-			}
-			path = filepath.Join(user.HomeDir, glob[1:])
-		} else {
-			path = filepath.FromSlash(filepath.Join(root, glob))
-		}
+	path, err := resolveGlob(root, glob)
+	if err != nil {
+		return empty, err
 	}
-	path, err = filepath.Abs(path)
 	files, err := zglob.Glob(path)
 
 	if err != nil {
 		return empty, err
 	}
 
+	scanRoot := globBase(path)
+	ignore := simplematch.NewMatcher(scanRoot, ignorePattern)
+	var vcs *vcsIgnore
+	if useVCSIgnore {
+		vcs = newVCSIgnore(scanRoot)
+	}
+	ignored := func(p string, isDir bool) bool {
+		if isDir && ignore.MatchesDir(p) || !isDir && ignore.Matches(p) {
+			return true
+		}
+		return vcs != nil && vcs.Ignored(p, isDir)
+	}
+
 	seenFolders := make(map[string]struct{})
 	result := FileNodes{}
 	for _, file := range files {
@@ -74,8 +76,8 @@ func ListFileNodes(root, glob, ignorePattern string) (FileNodes, error) {
 		}
 
 		if !fi.IsDir() {
-			result = gatherDirectories(root, filepath.Dir(file), result, seenFolders, ignore)
-			if ignore.Matches(file) {
+			result = gatherDirectories(root, filepath.Dir(file), result, seenFolders, ignored)
+			if ignored(file, false) {
 				continue
 			}
 			result = append(result, &FileNode{
@@ -88,7 +90,7 @@ func ListFileNodes(root, glob, ignorePattern string) (FileNodes, error) {
 	return result, nil
 }
 
-func gatherDirectories(root, dir string, result FileNodes, seenFolders map[string]struct{}, ignore *simplematch.Matcher) FileNodes {
+func gatherDirectories(root, dir string, result FileNodes, seenFolders map[string]struct{}, ignored func(string, bool) bool) FileNodes {
 	dir = filepath.Clean(dir)
 	for {
 		if dir == root {
@@ -99,7 +101,7 @@ func gatherDirectories(root, dir string, result FileNodes, seenFolders map[strin
 		}
 
 		seenFolders[dir] = struct{}{}
-		if ignore.Matches(dir) {
+		if ignored(dir, true) {
 			dir = filepath.Clean(filepath.Dir(dir))
 			continue
 		}
@@ -112,6 +114,47 @@ func gatherDirectories(root, dir string, result FileNodes, seenFolders map[strin
 	}
 }
 
+// ScanRoot returns the folder that the glob starts matching from.
+// Ignore and binary patterns are anchored to it.
+func ScanRoot(root, glob string) (string, error) {
+	path, err := resolveGlob(filepath.Clean(filepath.FromSlash(root)), glob)
+	if err != nil {
+		return "", err
+	}
+	return globBase(path), nil
+}
+
+// resolveGlob returns the absolute glob, resolving it from root if relative.
+func resolveGlob(root, glob string) (string, error) {
+	var path string
+	if filepath.IsAbs(glob) {
+		path = glob
+	} else {
+		if strings.HasPrefix(glob, "~") {
+			user, err := user.Current()
+			if err != nil {
+				return "", err
+			}
+			path = filepath.Join(user.HomeDir, glob[1:])
+		} else {
+			path = filepath.FromSlash(filepath.Join(root, glob))
+		}
+	}
+	return filepath.Abs(path)
+}
+
+// globBase returns the directory a glob starts matching from,
+// which is everything up to the first segment with a wildcard.
+func globBase(glob string) string {
+	segments := strings.Split(filepath.ToSlash(glob), "/")
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*?[{") {
+			return filepath.Clean(filepath.FromSlash(strings.Join(segments[:i], "/") + "/"))
+		}
+	}
+	return filepath.Dir(glob)
+}
+
 func getNodeType(path string) (NodeType, error) {
 	stat, err := os.Stat(path)
 	if err == nil {
@@ -1,17 +1,21 @@
 package lister_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"strings"
 
 	"github.com/jeffijoe/total-rename/lister"
 	"github.com/jeffijoe/total-rename/util"
+	"github.com/stretchr/testify/require"
 )
 
 func TestListFileNodes(t *testing.T) {
 	fixturePath := "../_fixtures/fixture1/input/**/*.*"
-	result, _ := lister.ListFileNodes(util.GetWD(), fixturePath, ".dotfolder|SPACE_STUFFS.js")
+	result, _ := lister.ListFileNodes(util.GetWD(), fixturePath, ".dotfolder|SPACE_STUFFS.js", false)
 
 	notContains(t, result, "SPACE_STUFFS.js", lister.NodeTypeFile)
 	notContains(t, result, ".dotfolder", lister.NodeTypeDir)
@@ -22,7 +26,7 @@ func TestListFileNodes(t *testing.T) {
 
 func TestListFileNodes_Nested(t *testing.T) {
 	fixturePath := "../_fixtures/fixture2/input/**/*.*"
-	result, _ := lister.ListFileNodes(util.GetWD(), fixturePath, "")
+	result, _ := lister.ListFileNodes(util.GetWD(), fixturePath, "", false)
 
 	contains(t, result, "space-a", lister.NodeTypeDir)
 	contains(t, result, "SPACE-a-a", lister.NodeTypeDir)
@@ -34,11 +38,64 @@ func TestListFileNodes_Nested(t *testing.T) {
 
 func TestListFileNodes_Ignoring(t *testing.T) {
 	fixturePath := "../_fixtures/fixture1/input/**/*.*"
-	result, _ := lister.ListFileNodes(util.GetWD(), fixturePath, ".dotfolder")
+	result, _ := lister.ListFileNodes(util.GetWD(), fixturePath, ".dotfolder", false)
 
 	notContains(t, result, "space-awesome", lister.NodeTypeFile)
 }
 
+func TestListFileNodes_VCSIgnore(t *testing.T) {
+	repo, err := ioutil.TempDir("", "total-rename-vcs")
+	require.NoError(t, err)
+	defer os.RemoveAll(repo)
+	files := map[string]string{
+		".git/info/exclude":           "*.log\n",
+		".gitignore":                  "# dependencies\nnode_modules/\n/build\n*.tmp\n!keep.tmp\ndocs/**/draft-*\n",
+		"src/.gitignore":              "generated/\n!important.tmp\n",
+		"src/space.js":                "",
+		"src/space.tmp":               "",
+		"src/important.tmp":           "",
+		"src/build/space.js":          "",
+		"src/generated/space.js":      "",
+		"keep.tmp":                    "",
+		"node_modules/space/index.js": "",
+		"build/space.js":              "",
+		"debug.log":                   "",
+		"docs/a/b/draft-space.md":     "",
+		"docs/space.md":               "",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	result, err := lister.ListFileNodes(repo, "**/*", "", true)
+	require.NoError(t, err)
+	contains(t, result, filepath.FromSlash("src/space.js"), lister.NodeTypeFile)
+	contains(t, result, filepath.FromSlash("src/important.tmp"), lister.NodeTypeFile)
+	contains(t, result, filepath.FromSlash("src/build/space.js"), lister.NodeTypeFile)
+	contains(t, result, filepath.FromSlash("src/build"), lister.NodeTypeDir)
+	contains(t, result, "keep.tmp", lister.NodeTypeFile)
+	contains(t, result, filepath.FromSlash("docs/space.md"), lister.NodeTypeFile)
+	notContains(t, result, "space.tmp", lister.NodeTypeFile)
+	notContains(t, result, "generated", lister.NodeTypeDir)
+	notContains(t, result, filepath.FromSlash("generated/space.js"), lister.NodeTypeFile)
+	notContains(t, result, "node_modules", lister.NodeTypeDir)
+	notContains(t, result, "index.js", lister.NodeTypeFile)
+	notContains(t, result, filepath.FromSlash(repo+"/build"), lister.NodeTypeDir)
+	notContains(t, result, filepath.FromSlash(repo+"/build/space.js"), lister.NodeTypeFile)
+	notContains(t, result, "debug.log", lister.NodeTypeFile)
+	notContains(t, result, "draft-space.md", lister.NodeTypeFile)
+	notContains(t, result, "exclude", lister.NodeTypeFile)
+
+	result, err = lister.ListFileNodes(repo, "**/*", "", false)
+	require.NoError(t, err)
+	contains(t, result, "space.tmp", lister.NodeTypeFile)
+	contains(t, result, filepath.FromSlash("generated/space.js"), lister.NodeTypeFile)
+	contains(t, result, "index.js", lister.NodeTypeFile)
+	contains(t, result, "debug.log", lister.NodeTypeFile)
+}
+
 func contains(t *testing.T, result []*lister.FileNode, name string, nodeType lister.NodeType) {
 	for _, f := range result {
 		if strings.HasSuffix(f.Path, name) {
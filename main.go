@@ -2,18 +2,30 @@ package main
 
 import (
 	"flag"
+	"io"
 	"os"
+	"os/exec"
 	"runtime"
 	"strconv"
+	"strings"
 
 	"fmt"
 
 	"github.com/fatih/color"
 	"github.com/jeffijoe/total-rename/casing"
 	"github.com/jeffijoe/total-rename/cli"
+	"github.com/jeffijoe/total-rename/config"
+	"github.com/jeffijoe/total-rename/inflection"
+	"github.com/jeffijoe/total-rename/journal"
 	"github.com/jeffijoe/total-rename/lister"
+	"github.com/jeffijoe/total-rename/mapping"
+	"github.com/jeffijoe/total-rename/patch"
 	"github.com/jeffijoe/total-rename/replacer"
+	"github.com/jeffijoe/total-rename/report"
+	"github.com/jeffijoe/total-rename/review"
 	"github.com/jeffijoe/total-rename/scanner"
+	"github.com/jeffijoe/total-rename/simplematch"
+	"github.com/jeffijoe/total-rename/tui"
 	"github.com/jeffijoe/total-rename/util"
 )
 
@@ -21,9 +33,36 @@ func main() {
 	help := flag.Bool("help", false, "Shows the help menu")
 	dryRun := flag.Bool("dry", false, "If set, won't rename anything.")
 	force := flag.Bool("force", false, "Replaces all occurences without asking")
-	binaryPattern := flag.String("binary", "", "A | separated string of path segments where contents should not be examined")
-	ignorePattern := flag.String("ignore", "", "A | separated string of path segments where files/folders be ignored completely")
+	binaryPattern := flag.String("binary", "", "A | separated string of glob patterns of files whose contents should not be examined")
+	textPattern := flag.String("text", "", "A | separated string of glob patterns of files whose contents are examined even if they look binary")
+	ignorePattern := flag.String("ignore", "", "A | separated string of glob patterns of files/folders to ignore completely")
+	noVCSIgnore := flag.Bool("no-vcs-ignore", false, "Don't skip files ignored by .gitignore files")
+	mapFile := flag.String("map", "", "A file of needle/replacement pairs to rename in one pass")
+	plural := flag.Bool("plural", false, "Also renames plural forms, pairing them with the plural of the replacement")
+	irregularPattern := flag.String("irregular", "", "A | separated string of singular:plural pairs for --plural")
+	regex := flag.Bool("regex", false, "Treats needles as regular expressions")
+	boundary := flag.Bool("boundary", false, "Only matches needles that start and end on a word boundary")
+	replaceCasingPattern := flag.String("replace-casing", "", "A | separated string of [needle:]casing=replacement pairs that override the generated replacements, like upper=BRD")
+	mimic := flag.Bool("mimic", false, "Also renames needles in irregular casings, copying their upper and lower case letters")
+	acronymsPattern := flag.String("acronyms", "", "A | separated string of words to add to the acronyms, like SKU|GQL")
+	skipCasingsPattern := flag.String("skip-casings", "", "A | separated string of casings not to search for, like space|sentence")
+	collisionPolicy := flag.String("collisions", "abort", "How to resolve renames to paths that are taken: abort, skip, merge or suffix")
+	diff := flag.Bool("diff", false, "Prints the changes as a patch instead of making them")
+	diffFile := flag.String("diff-file", "", "Writes the changes to a patch file instead of making them")
+	allOrNothing := flag.Bool("all-or-nothing", false, "Rolls back every change when one of them fails")
+	jsonOutput := flag.Bool("json", false, "Prints the occurences as JSON instead of renaming them")
+	ndjsonOutput := flag.Bool("ndjson", false, "Prints the occurences as newline delimited JSON instead of renaming them")
+	contextLines := flag.Int("context", 3, "The number of lines shown around each occurence")
+	fullScreen := flag.Bool("tui", false, "Reviews the occurences in a full-screen reviewer instead of prompting for each")
+	preset := flag.String("preset", "", "Applies the options of a preset from the "+config.FileName+" file")
 	flag.Parse()
+	configPath, configErr := applyConfig(*preset)
+	stdout := os.Stdout
+	if *jsonOutput || *ndjsonOutput {
+		// Everything but the report goes to stderr,
+		// so stdout can be piped into other tools.
+		os.Stdout = os.Stderr
+	}
 	fmt.Println("total-rename - case-preserving renaming utility")
 	fmt.Println("Copyright © Jeff Hansen 2017 to present. All rights reserved.")
 	fmt.Println()
@@ -32,6 +71,34 @@ func main() {
 		return
 	}
 
+	if configErr != nil {
+		fmt.Printf("Invalid config: %v\n", configErr)
+		os.Exit(1)
+	}
+	if configPath != "" {
+		fmt.Printf("Using options from %s\n", configPath)
+	}
+
+	if flag.Arg(0) == "undo" {
+		undo(flag.Args()[1:])
+		return
+	}
+
+	args := flag.Args()
+	planFile := ""
+	if flag.Arg(0) == "plan" {
+		if len(args) < 2 {
+			fmt.Println("Not enough arguments, expects a plan file: plan <plan.json> <path> <needle> <replacement>")
+			return
+		}
+		planFile = args[1]
+		args = args[2:]
+	}
+	find := flag.Arg(0) == "find"
+	if find {
+		args = args[1:]
+	}
+
 	if *dryRun {
 		fmt.Println("--dry active; won't rename anything.")
 	}
@@ -40,133 +107,504 @@ func main() {
 		fmt.Println("--force active; won't prompt for confirmation")
 	}
 
+	if *diff || *diffFile != "" {
+		fmt.Println("--diff active; won't rename anything, but write a patch")
+	}
+
+	if *plural {
+		fmt.Println("--plural active; will rename plural forms too")
+	}
+
+	if *boundary {
+		fmt.Println("--boundary active; only whole words will be matched")
+	}
+
+	if *mimic {
+		fmt.Println("--mimic active; irregular casings will be renamed too")
+	}
+
+	if *allOrNothing {
+		fmt.Println("--all-or-nothing active; will roll back if anything fails")
+	}
+
+	if *regex {
+		fmt.Println("--regex active; needles are regular expressions")
+		if *plural {
+			fmt.Println("--regex can not be combined with --plural")
+			return
+		}
+	}
+
+	policy, err := replacer.ParseCollisionPolicy(*collisionPolicy)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	options := &applyOptions{
+		policy:       policy,
+		dryRun:       *dryRun,
+		diff:         *diff || *diffFile != "",
+		diffFile:     *diffFile,
+		allOrNothing: *allOrNothing,
+	}
+
+	if flag.Arg(0) == "apply" {
+		fmt.Println()
+		applyPlan(args[1:], options)
+		return
+	}
+
 	fmt.Println()
-	if flag.NArg() < 3 {
-		fmt.Println("Not enough arguments, expects 3: <path> <needle> <replacement>")
+	var pairs mapping.Pairs
+	if *mapFile != "" {
+		if len(args) < 1 {
+			fmt.Println("Not enough arguments, expects 1 when using --map: <path>")
+			return
+		}
+		var err error
+		pairs, err = mapping.Load(*mapFile)
+		if err != nil {
+			fmt.Printf("Could not read mapping file %s: %v\n", *mapFile, err)
+			return
+		}
+	} else if find {
+		if len(args) < 2 {
+			fmt.Println("Not enough arguments, expects 2: find <path> <needle>")
+			return
+		}
+		pairs = mapping.Pairs{mapping.Pair{Needle: args[1], Replacement: args[1]}}
+	} else {
+		if len(args) < 3 {
+			fmt.Println("Not enough arguments, expects 3: <path> <needle> <replacement>")
+			return
+		}
+		pairs = mapping.Pairs{mapping.Pair{Needle: args[1], Replacement: args[2]}}
+	}
+	if err := pairs.Validate(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	path := args[0]
+	casing.AddAcronyms(strings.Split(*acronymsPattern, "|")...)
+	needles := scanner.NewNeedles(pairs.Needles()...)
+	replacements := replacer.NewReplacements(pairs)
+	var rules *inflection.Rules
+	if *regex {
+		var err error
+		needles, err = scanner.NewRegexpNeedles(pairs.Needles()...)
+		if err != nil {
+			fmt.Printf("Invalid regular expression: %v\n", err)
+			return
+		}
+	} else if *plural {
+		rules = inflection.English()
+		if err := rules.AddIrregulars(*irregularPattern); err != nil {
+			fmt.Println(err)
+			return
+		}
+		needles = scanner.NewInflectedNeedles(rules, pairs.Needles()...)
+		replacements = replacer.NewInflectedReplacements(rules, pairs)
+	}
+	if *boundary {
+		needles.RequireWordBoundaries()
+	}
+	skipCasings, err := casing.ParseList(*skipCasingsPattern)
+	if err != nil {
+		fmt.Println(err)
 		return
 	}
-	replacement := flag.Arg(2)
-	path := flag.Arg(0)
-	needle := flag.Arg(1)
-	nodes, err := lister.ListFileNodes(util.GetWD(), path, *ignorePattern)
+	needles.SkipCasings(skipCasings...)
+	overrides, err := replacer.ParseCasingOverrides(*replaceCasingPattern)
+	if err == nil {
+		err = replacements.OverrideCasings(overrides, rules)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if *mimic {
+		needles.MimicCasings()
+	}
+	nodes, err := lister.ListFileNodes(util.GetWD(), path, *ignorePattern, !*noVCSIgnore)
 	if err != nil {
 		panic(err)
 	}
-	var groups scanner.OccurenceGroups
-	if *force {
-		groups, err = scanner.ScanFileNodes(nodes, needle, *binaryPattern)
-	} else {
-		groups, err = promptOccurences(nodes, needle, replacement, *binaryPattern)
+	scanRoot, err := lister.ScanRoot(util.GetWD(), path)
+	if err != nil {
+		panic(err)
 	}
+	binary := simplematch.NewMatcher(scanRoot, *binaryPattern)
+	text := simplematch.NewMatcher(scanRoot, *textPattern)
+	groups, binaries, err := scanner.ScanFileNodes(nodes, needles, binary, text)
 	if err != nil {
 		panic(err)
 	}
-
-	rename := os.Rename
-	replace := replacer.ReplaceFileContent
-	if *dryRun {
-		rename = func(p1, p2 string) error {
-			return nil
+	if *jsonOutput || *ndjsonOutput {
+		r := report.New(groups, binaries, replacements)
+		write := r.WriteJSON
+		if *ndjsonOutput {
+			write = r.WriteNDJSON
+		}
+		if err := write(stdout); err != nil {
+			panic(err)
 		}
-		replace = func(p1, p2 string) error {
-			return nil
+		return
+	}
+	if find {
+		printFinds(groups)
+		return
+	}
+	printBinaries(binaries)
+	if !*force && *fullScreen {
+		if !tui.IsTerminal() {
+			fmt.Println("--tui needs a terminal; nothing was renamed.")
+			os.Exit(1)
+		}
+		groups, err = tui.Review(groups, replacements, util.GetWD(), *contextLines)
+		if err == tui.ErrAborted {
+			fmt.Println("Aborted, nothing was renamed.")
+			os.Exit(1)
+		}
+		if err != nil {
+			panic(err)
+		}
+	} else if !*force {
+		groups, err = promptOccurences(groups, replacements, *contextLines)
+		if err == cli.ErrInterrupted || err == io.EOF {
+			fmt.Println("Aborted, nothing was renamed.")
+			os.Exit(1)
+		}
+		if err != nil {
+			panic(err)
 		}
 	}
 
-	result, err := replacer.TotalRename(groups, replacement, rename, replace)
+	if planFile != "" {
+		savePlan(planFile, groups, replacements)
+		return
+	}
+	applyGroups(groups, replacements, options)
+}
+
+// applyOptions determine how the changes are applied.
+type applyOptions struct {
+	policy       replacer.CollisionPolicy
+	dryRun       bool
+	diff         bool
+	diffFile     string
+	allOrNothing bool
+}
+
+// applyGroups renames the occurences in the groups, journaling the changes.
+func applyGroups(groups scanner.OccurenceGroups, replacements replacer.Replacements, options *applyOptions) {
+	changes, err := replacer.StageChanges(groups, replacements)
+	if err != nil {
+		panic(err)
+	}
+	changes, collisions, err := replacer.ResolveCollisions(changes, options.policy)
+	printCollisions(collisions)
+	if err != nil {
+		fmt.Println("Nothing was renamed. Use --collisions skip, merge or suffix to resolve them.")
+		os.Exit(1)
+	}
+	if options.dryRun {
+		fmt.Printf("Done! Would have renamed %d occurences!", changes.Occurences())
+		fmt.Println()
+		return
+	}
+	if options.diff {
+		if err := writePatch(changes, options.diffFile); err != nil {
+			fmt.Printf("Could not write the patch: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	j := journal.New(os.Args[1:])
+	autosaveJournal(j)
+	rename := j.Rename(os.Rename)
+	replace := j.ReplaceFile(replacer.ReplaceFileContent)
+	apply := changes.Apply
+	if options.allOrNothing {
+		apply = func(rename replacer.RenameFunc, replace replacer.ReplaceFileFunc) (*replacer.TotalRenameResult, error) {
+			return changes.ApplyOrRollBack(rename, replace, j.RenameBack(os.Rename), j.ReplaceFileBack(replacer.ReplaceFileContent))
+		}
+	}
+	result, err := apply(rename, replace)
+	rollbackErr, rolledBack := err.(*replacer.RollbackError)
+	fullyRolledBack := rolledBack && len(rollbackErr.NotRestored) == 0
+	saved := false
+	if len(j.Entries) > 0 && !fullyRolledBack {
+		saved = saveJournal(j)
+	} else {
+		discardJournal(j)
+	}
+	if rolledBack {
+		printRollback(rollbackErr)
+		if saved {
+			fmt.Println("Run \"total-rename undo\" to revert the changes that were not rolled back.")
+		}
+		os.Exit(1)
+	}
 	if err != nil {
 		panic(err)
 	}
 	fmt.Printf("Done! Renamed %d occurences!", result.OccurencesRenamed)
 	fmt.Println()
+	if saved {
+		fmt.Println("Run \"total-rename undo\" to revert these changes.")
+	}
+}
+
+// printBinaries reports the files whose contents were skipped because they look binary.
+func printBinaries(binaries []string) {
+	if len(binaries) == 0 {
+		return
+	}
+	fmt.Printf("Only renaming the paths of %d files that look binary (use --text to examine their contents):\n", len(binaries))
+	for _, p := range binaries {
+		fmt.Println("    " + p)
+	}
+	fmt.Println()
 }
 
-func promptOccurences(nodes lister.FileNodes, needle, replacement string, binaryPattern string) (scanner.OccurenceGroups, error) {
-	groups, err := scanner.ScanFileNodes(nodes, needle, binaryPattern)
+// writePatch writes the changes as a patch to the file,
+// or to stdout when no file is specified.
+func writePatch(changes replacer.Changes, filePath string) error {
+	recorder := patch.NewRecorder(util.GetWD())
+	if _, err := changes.Apply(recorder.Rename, recorder.ReplaceFile); err != nil {
+		return err
+	}
+	if filePath == "" {
+		_, err := recorder.WriteTo(os.Stdout)
+		return err
+	}
+	f, err := os.Create(filePath)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	replacementVariants := casing.GenerateCasings(replacement)
-	result := scanner.OccurenceGroups{}
-	for _, group := range groups {
-		var newGroup *scanner.OccurenceGroup
-		switch group.Type {
-		case scanner.OccurenceGroupTypeContent:
-			newGroup, err = promptGroup(group, replacementVariants, promptContentOccurence)
-		case scanner.OccurenceGroupTypePath:
-			newGroup, err = promptGroup(group, replacementVariants, promptPathOccurence)
-		}
-		if err != nil {
-			return nil, err
-		}
-		if newGroup != nil {
-			result = append(result, newGroup)
+	if _, err := recorder.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Done! Wrote %d occurences to %s\n", changes.Occurences(), filePath)
+	return nil
+}
+
+// printCollisions reports the renames to paths that are taken, and how they were resolved.
+func printCollisions(collisions []*replacer.Collision) {
+	if len(collisions) == 0 {
+		return
+	}
+	fmt.Printf("Found %d renames to paths that are taken:\n", len(collisions))
+	for _, c := range collisions {
+		fmt.Println("    " + c.String())
+	}
+	fmt.Println()
+}
+
+// printRollback reports what was rolled back after a change failed.
+func printRollback(err *replacer.RollbackError) {
+	fmt.Printf("Could not %s: %v\n", err.Failed, err.Err)
+	fmt.Println()
+	fmt.Printf("Rolled back %d changes:\n", len(err.Restored))
+	for _, c := range err.Restored {
+		fmt.Println("    " + c.String())
+	}
+	if len(err.NotRestored) > 0 {
+		fmt.Println()
+		fmt.Printf("Could not roll back %d changes:\n", len(err.NotRestored))
+		for _, f := range err.NotRestored {
+			fmt.Printf("    %s: %v\n", f.Change, f.Err)
 		}
 	}
+}
 
-	return result, nil
+// promptKeys explains the keys the prompt accepts.
+var promptKeys = []string{
+	"y  rename this occurence (or Enter)",
+	"n  skip this occurence",
+	"e  type a different replacement for this occurence",
+	"a  rename the rest of the occurences in this file",
+	"d  skip the rest of the occurences in this file",
+	"A  rename every remaining occurence in this casing",
+	"q  rename what has been accepted so far and stop",
+	"b  go back one occurence",
+	"+  show more lines around this occurence",
+	"-  show fewer lines around this occurence",
+	"p  open the file at this occurence in $PAGER, or less",
+	"?  show this help",
 }
 
-// OccurencePrompter is a function that prompts the user whether the occurence should be replaced or not.
-type OccurencePrompter func(occurence *scanner.Occurence, replacementVariants casing.Variants, w *cli.Wrapper) (bool, error)
+// contextStep is the number of lines + and - add or remove.
+const contextStep = 5
 
-func promptGroup(group *scanner.OccurenceGroup, replacementVariants casing.Variants, promptOccurence OccurencePrompter) (*scanner.OccurenceGroup, error) {
+// promptOccurences asks for every occurence whether it should be replaced,
+// one key press at a time, and returns the groups with the accepted ones.
+// contextLines is the number of lines shown around content occurences,
+// until the user shows more or fewer.
+func promptOccurences(groups scanner.OccurenceGroups, replacements replacer.Replacements, contextLines int) (scanner.OccurenceGroups, error) {
 	w := cli.Clearable()
-
-	occurences := scanner.Occurences{}
-	result := &scanner.OccurenceGroup{
-		Path: group.Path,
-		Type: group.Type,
-	}
-	countReplaced := 0
-	countSkipped := 0
-	printFileStatus := func(printf func(string, ...interface{}) (int, error)) {
-		color.Set(color.BgWhite)
-		color.Set(color.FgBlack)
-		printf(group.Path)
-		color.Set(color.BgGreen)
-
-		if countReplaced > 0 {
-			printf(" %d replaced", countReplaced)
+	session := review.NewSession(groups)
+	context := scanner.NewContextReader()
+	showKeys := false
+	var last *review.Item
+	lines := contextLines
+	for !session.Done() {
+		item := session.Current()
+		if item != last {
+			last = item
+			lines = contextLines
 		}
-		if countSkipped > 0 {
-			if countReplaced > 0 {
-				printf(", %d skipped", countSkipped)
-			} else {
-				printf(": %d skipped", countSkipped)
-
+		printFileStatus(session, item.Group, w.Printf)
+		w.Println()
+		switch item.Group.Type {
+		case scanner.OccurenceGroupTypeContent:
+			// The context is left out when the file can not be read.
+			before, after, err := context.Lines(item.Group.Path, item.Occurence.LineNumber, lines)
+			if err != nil {
+				w.Printf("Could not read the surrounding lines: %v\n", err)
+				before, after = []string{}, []string{}
 			}
+			promptContentOccurence(item.Occurence, before, after, replacements, w)
+		case scanner.OccurenceGroupTypePath:
+			promptPathOccurence(item.Occurence, replacements, w)
 		}
-		color.Unset()
-		printf("\n")
-	}
-	for _, oc := range group.Occurences {
-		printFileStatus(w.Printf)
-		w.Println()
-		shouldReplace, err := promptOccurence(oc, replacementVariants, w)
+		if showKeys {
+			w.Println()
+			for _, k := range promptKeys {
+				w.Println("    " + k)
+			}
+			showKeys = false
+		}
+
+		key, err := w.ReadKey()
+		w.Clear()
 		if err != nil {
 			return nil, err
 		}
-		if shouldReplace {
-			countReplaced = countReplaced + 1
-			occurences = append(occurences, oc)
-		} else {
-			countSkipped = countSkipped + 1
+		switch key {
+		case 'y', 'Y', '\n':
+			session.Accept()
+		case 'n', 'N':
+			session.Skip()
+		case 'e':
+			override, err := promptOverride(item.Occurence, w)
+			if err != nil {
+				return nil, err
+			}
+			// A separator would move the path into another folder.
+			if item.Group.Type == scanner.OccurenceGroupTypePath && strings.ContainsAny(override, `/\`) {
+				w.Printf("%q can not be used in a path, it holds a path separator\n", override)
+				break
+			}
+			if override != "" {
+				session.AcceptAs(override)
+			}
+		case 'a':
+			session.AcceptGroup()
+		case 'd':
+			session.SkipGroup()
+		case 'A':
+			session.AcceptCasing()
+		case 'q':
+			session.Quit()
+		case 'b':
+			session.Back()
+		case '+':
+			lines = lines + contextStep
+		case '-':
+			lines = lines - contextStep
+			if lines < 0 {
+				lines = 0
+			}
+		case 'p':
+			if item.Group.Type != scanner.OccurenceGroupTypeContent {
+				showKeys = true
+				break
+			}
+			if err := openPager(item.Group.Path, item.Occurence.LineNumber+1); err != nil {
+				w.Printf("Could not open the pager: %v\n", err)
+			}
+		default:
+			showKeys = true
 		}
-
-		w.Clear()
 	}
 	if runtime.GOOS != "windows" {
-		printFileStatus(fmt.Printf)
+		for _, group := range groups {
+			printFileStatus(session, group, fmt.Printf)
+		}
 	}
-	if len(occurences) == 0 {
-		return nil, nil
+	return session.Groups(), nil
+}
+
+// promptOverride asks for the replacement of a single occurence,
+// returning an empty string when nothing was typed.
+func promptOverride(occurence *scanner.Occurence, w *cli.Wrapper) (string, error) {
+	color.Set(color.FgWhite)
+	w.Print("Replace ")
+	color.Set(color.FgYellow)
+	w.Print(occurence.Match)
+	color.Set(color.FgWhite)
+	w.Print(" in this spot only with (leave empty to cancel): ")
+	color.Unset()
+	line, err := w.ReadLine()
+	w.Clear()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// openPager shows the file at the 1-based line in the pager from $PAGER,
+// or less.
+func openPager(filePath string, line int) error {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+		if runtime.GOOS == "windows" {
+			pager = []string{"more"}
+		}
+	}
+	args := append(pager[1:], "+"+strconv.Itoa(line), filePath)
+	cmd := exec.Command(pager[0], args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// printFileStatus prints the path of the group with the number of occurences
+// replaced and skipped in it. While prompting, the overall progress and the
+// keys to choose with are printed as well.
+func printFileStatus(session *review.Session, group *scanner.OccurenceGroup, printf func(string, ...interface{}) (int, error)) {
+	replaced, skipped := session.Counts(group)
+	color.Set(color.BgWhite)
+	color.Set(color.FgBlack)
+	printf(group.Path)
+	color.Set(color.BgGreen)
+
+	if replaced > 0 {
+		printf(" %d replaced", replaced)
+	}
+	if skipped > 0 {
+		if replaced > 0 {
+			printf(", %d skipped", skipped)
+		} else {
+			printf(": %d skipped", skipped)
+		}
+	}
+	color.Unset()
+	if !session.Done() {
+		printf(" %d/%d [y,n,e,a,d,A,q,b,+,-,p,?]", session.Position()+1, len(session.Items))
 	}
-	result.Occurences = occurences
-	return result, nil
+	printf("\n")
 }
 
-func promptPathOccurence(occurence *scanner.Occurence, replacementVariants casing.Variants, w *cli.Wrapper) (bool, error) {
+func promptPathOccurence(occurence *scanner.Occurence, replacements replacer.Replacements, w *cli.Wrapper) {
 	color.Set(color.FgHiBlack)
 	beforeMatch := occurence.Line[:occurence.LineStartIndex]
 	afterMatch := occurence.Line[occurence.LineStartIndex+len(occurence.Match):]
@@ -186,17 +624,16 @@ func promptPathOccurence(occurence *scanner.Occurence, replacementVariants casin
 	color.Set(color.FgWhite)
 	w.Print(" with ")
 	color.Set(color.FgGreen)
-	w.Print(replacementVariants.GetVariant(occurence.Casing).Value)
+	w.Print(replacements.Replace(occurence))
 	color.Set(color.FgWhite)
-	w.Println("? [Y/n] ")
-	response, err := w.Confirm(true)
-	return response, err
+	w.Println("?")
+	color.Unset()
 }
 
-func promptContentOccurence(occurence *scanner.Occurence, replacementVariants casing.Variants, w *cli.Wrapper) (bool, error) {
+func promptContentOccurence(occurence *scanner.Occurence, linesBefore, linesAfter []string, replacements replacer.Replacements, w *cli.Wrapper) {
 	color.Set(color.FgHiBlack)
-	for i, ln := range occurence.SurroundingLinesBefore {
-		lineNum := occurence.LineNumber + i + 1 - len(occurence.SurroundingLinesBefore)
+	for i, ln := range linesBefore {
+		lineNum := occurence.LineNumber + i + 1 - len(linesBefore)
 		w.Println(formatLine(lineNum, ln))
 	}
 	beforeMatch := occurence.Line[:occurence.LineStartIndex]
@@ -206,7 +643,7 @@ func promptContentOccurence(occurence *scanner.Occurence, replacementVariants ca
 	w.Print(occurence.Match)
 	color.Set(color.FgHiBlack)
 	w.Println(afterMatch)
-	for i, ln := range occurence.SurroundingLinesAfter {
+	for i, ln := range linesAfter {
 		lineNum := occurence.LineNumber + i + 2
 		w.Println(formatLine(lineNum, ln))
 	}
@@ -219,11 +656,10 @@ func promptContentOccurence(occurence *scanner.Occurence, replacementVariants ca
 	color.Set(color.FgWhite)
 	w.Print(" with ")
 	color.Set(color.FgGreen)
-	w.Print(replacementVariants.GetVariant(occurence.Casing).Value)
+	w.Print(replacements.Replace(occurence))
 	color.Set(color.FgWhite)
-	w.Print("? [Y/n] ")
-	response, err := w.Confirm(true)
-	return response, err
+	w.Println("?")
+	color.Unset()
 }
 
 func formatLine(lineNum int, str string) string {
@@ -235,10 +671,100 @@ func printHelp() {
 	fmt.Println("    Options must be specified before arguments.")
 	fmt.Println("")
 	fmt.Println("    --dry         If set, won't rename anything")
-	fmt.Println("    --binary      A | separated string of path segments where contents")
-	fmt.Println("                  should not be examined.")
-	fmt.Println("    --ignore      A | separated string of path segments to completely ignore")
+	fmt.Println("    --binary      A | separated string of glob patterns of files whose")
+	fmt.Println("                  contents should not be examined. Files that look")
+	fmt.Println("                  binary (NUL bytes, invalid UTF-8, known file")
+	fmt.Println("                  signatures) are detected automatically.")
+	fmt.Println("    --text        A | separated string of glob patterns of files whose")
+	fmt.Println("                  contents are examined even if they look binary.")
+	fmt.Println("    --ignore      A | separated string of glob patterns of files and")
+	fmt.Println("                  folders to completely ignore.")
+	fmt.Println("    --no-vcs-ignore")
+	fmt.Println("                  Don't skip files and folders ignored by .gitignore")
+	fmt.Println("                  files and .git/info/exclude")
 	fmt.Println("    --force       Replaces all occurences without asking")
+	fmt.Println("    --tui         Reviews the occurences in a full-screen reviewer instead")
+	fmt.Println("                  of prompting for each, with the files on the left and")
+	fmt.Println("                  the occurence and a preview of its line on the right.")
+	fmt.Println("                  Every occurence starts out selected. Use up and down to")
+	fmt.Println("                  move, left and right to switch files, space to toggle")
+	fmt.Println("                  an occurence, a to toggle a file, + and - to show more")
+	fmt.Println("                  or fewer lines around it, Enter to rename the")
+	fmt.Println("                  selected occurences and q to quit without renaming.")
+	fmt.Println("    --context     The number of lines shown around each occurence in")
+	fmt.Println("                  the prompt and --tui. Defaults to 3.")
+	fmt.Println("    --diff        Prints the changes as a patch instead of making them.")
+	fmt.Println("                  Content changes are written as unified diffs and")
+	fmt.Println("                  renames as git rename headers, relative to the working")
+	fmt.Println("                  directory, so \"git apply\" accepts the patch.")
+	fmt.Println("    --diff-file   Like --diff, but writes the patch to the specified file.")
+	fmt.Println("    --map         A file of needle/replacement pairs to rename in one")
+	fmt.Println("                  pass. One \"<find> <replace>\" pair per line, or a")
+	fmt.Println("                  JSON object / YAML mapping of <find> to <replace>.")
+	fmt.Println("                  Replaces the <find> and <replace> arguments.")
+	fmt.Println("    --plural      Also renames plural forms of <find> to the plural")
+	fmt.Println("                  form of <replace>. Both should be given in singular.")
+	fmt.Println("    --irregular   A | separated string of singular:plural pairs that")
+	fmt.Println("                  --plural would otherwise get wrong.")
+	fmt.Println("    --regex       Treats <find> as a case-insensitive regular expression.")
+	fmt.Println("                  <replace> may refer to captured groups with ${1} or")
+	fmt.Println("                  ${name}, which are inserted as they are; the rest of")
+	fmt.Println("                  <replace> is written in the casing of the match")
+	fmt.Println("                  without them. Can not be combined with --plural.")
+	fmt.Println("    --boundary    Only matches <find> when it starts and ends on a word")
+	fmt.Println("                  boundary: camelCase humps, _, -, . and other")
+	fmt.Println("                  non-alphanumerics. \"space\" then matches mySpaceList")
+	fmt.Println("                  but not workspace. Combine with --plural to also")
+	fmt.Println("                  match \"spaces\".")
+	fmt.Println("    --mimic       Also renames <find> in irregular casings, like sPaCe or")
+	fmt.Println("                  SPACe, copying their upper and lower case letters onto")
+	fmt.Println("                  <replace>: letter by letter when both are as long, and")
+	fmt.Println("                  word by word otherwise, so SPACe becomes BOARd.")
+	fmt.Println("    --skip-casings")
+	fmt.Println("                  A | separated string of casings not to search for.")
+	fmt.Println("                  Every occurence is searched for in these casings:")
+	fmt.Println("                  original, lower, upper, camel, title, snake, kebab,")
+	fmt.Println("                  upper-snake, upper-kebab, dot (space.name), path")
+	fmt.Println("                  (space/name), train (Space-Name), space (space name),")
+	fmt.Println("                  sentence (Space name), acronym-camel (userID) and")
+	fmt.Println("                  acronym-title (APIKey). Has no effect with --regex.")
+	fmt.Println("    --replace-casing")
+	fmt.Println("                  A | separated string of casing=replacement pairs, like")
+	fmt.Println("                  \"upper=BRD|title=WorkBoard\", that replace <find> in")
+	fmt.Println("                  those casings instead of <replace> in that casing.")
+	fmt.Println("                  With several needles, prefix each pair with its")
+	fmt.Println("                  needle, like \"space:upper=BRD\". With --plural, plural")
+	fmt.Println("                  forms are replaced with the plural of the replacement.")
+	fmt.Println("                  Takes a mapping of casings to replacements in")
+	fmt.Println("                  " + config.FileName + ".")
+	fmt.Println("    --acronyms    A | separated string of words to add to the acronyms,")
+	fmt.Println("                  which the acronym casings write in upper case. API,")
+	fmt.Println("                  HTTP, ID, JSON, URL, UUID and other common ones are")
+	fmt.Println("                  known already.")
+	fmt.Println("    --collisions  How to resolve renames to paths that already exist or")
+	fmt.Println("                  that other paths are renamed to as well. One of:")
+	fmt.Println("                  abort   Don't change anything (default)")
+	fmt.Println("                  skip    Leave the colliding paths as they are")
+	fmt.Println("                  merge   Move the contents of a folder into the")
+	fmt.Println("                          folder it collides with")
+	fmt.Println("                  suffix  Add a number, like board-2.js")
+	fmt.Println("    --all-or-nothing")
+	fmt.Println("                  When a change fails, rolls back the changes")
+	fmt.Println("                  already made.")
+	fmt.Println("    --json        Prints the occurences as a JSON report instead of")
+	fmt.Println("                  prompting or renaming anything. Other output goes to")
+	fmt.Println("                  stderr. The report has a \"version\" and a list of")
+	fmt.Println("                  \"groups\", each with a \"type\" (content, path or binary),")
+	fmt.Println("                  \"path\" and \"occurences\". Every occurence has its")
+	fmt.Println("                  \"needle\", \"number\", \"casing\", \"match\", 1-based \"line\"")
+	fmt.Println("                  and \"column\", byte \"offset\" and \"replacement\". When")
+	fmt.Println("                  a match is written the same in several casings, like")
+	fmt.Println("                  \"space\", they are listed in \"casings\", and \"casing\" is")
+	fmt.Println("                  the one it is replaced in.")
+	fmt.Println("    --ndjson      Like --json, but prints one group per line, each with")
+	fmt.Println("                  the \"version\".")
+	fmt.Println("    --preset      Applies the options of a named preset from the")
+	fmt.Println("                  " + config.FileName + " file. See CONFIG.")
 	fmt.Println("    --help        Shows this help text")
 	fmt.Println("")
 	fmt.Println("ARGUMENTS:")
@@ -250,6 +776,63 @@ func printHelp() {
 	fmt.Println("    <replace>  The string to replace occurences with.")
 	fmt.Println("               If multiple words, please use camelCase.")
 	fmt.Println("")
+	fmt.Println("COMMANDS:")
+	fmt.Println("")
+	fmt.Println("    undo [<id>]  Reverts the latest run, or the run with the specified id.")
+	fmt.Println("                 Every run without --dry keeps a journal of its changes")
+	fmt.Println("                 in the user cache folder, which keeps the last 20 runs.")
+	fmt.Println("                 Refuses to undo anything when a changed file has been")
+	fmt.Println("                 edited since.")
+	fmt.Println("    undo --list  Lists the runs that can be undone, newest first.")
+	fmt.Println("    plan <plan.json> <path> <needle> <replacement>")
+	fmt.Println("                 Scans and prompts like a regular run, but writes the")
+	fmt.Println("                 selected occurences to a plan file instead of renaming.")
+	fmt.Println("                 Accepts the same options and --map.")
+	fmt.Println("    apply <plan.json>")
+	fmt.Println("                 Applies a plan file in the current folder. Refuses to")
+	fmt.Println("                 apply it when a planned file has changed since.")
+	fmt.Println("    find <path> <needle>")
+	fmt.Println("                 Lists the occurences of <needle> in every casing as")
+	fmt.Println("                 path:line:column: match [casing], without renaming")
+	fmt.Println("                 anything, followed by the number of occurences per")
+	fmt.Println("                 casing and per folder. Accepts the same options.")
+	fmt.Println("")
+	fmt.Println("PROMPT:")
+	fmt.Println("")
+	fmt.Println("    Unless --force is set, every occurence is shown for confirmation.")
+	fmt.Println("    Answer with a single key; Ctrl-C stops without renaming anything.")
+	fmt.Println("")
+	for _, k := range promptKeys {
+		fmt.Println("    " + k)
+	}
+	fmt.Println("")
+	fmt.Println("PATTERNS:")
+	fmt.Println("")
+	fmt.Println("    --binary, --text and --ignore patterns are globs anchored to the folder")
+	fmt.Println("    the search pattern starts in. A glob without a slash matches a file or")
+	fmt.Println("    folder name at any depth (\"dist\", \"*.png\"), a leading slash anchors it")
+	fmt.Println("    (\"/build\"). Supports **, *, ?, [a-z] and [!a-z]. A trailing slash only")
+	fmt.Println("    matches folders, and a leading ! excludes what earlier patterns matched.")
+	fmt.Println("    Prefix a pattern with \"contains:\" to match any path containing it,")
+	fmt.Println("    ignoring case, like older versions did.")
+	fmt.Println("")
+	fmt.Println("CONFIG:")
+	fmt.Println("")
+	fmt.Println("    Options are read from the first " + config.FileName + " file found in the")
+	fmt.Println("    working directory or a folder above it. Its keys are option names")
+	fmt.Println("    without the dashes, and lists are joined with |. The options of a")
+	fmt.Println("    preset are applied on top when it is picked with --preset. Options")
+	fmt.Println("    passed on the command line override both.")
+	fmt.Println("    The map and diff-file paths are relative to the folder of the file.")
+	fmt.Println("")
+	fmt.Println("        ignore: [dist, \"*.min.js\"]")
+	fmt.Println("        boundary: true")
+	fmt.Println("        context: 5")
+	fmt.Println("        presets:")
+	fmt.Println("          web:")
+	fmt.Println("            binary: [\"*.png\", \"*.svg\"]")
+	fmt.Println("            collisions: suffix")
+	fmt.Println("")
 	fmt.Println("EXAMPLE:")
 	fmt.Println("")
 	fmt.Println("    total-rename \"**/*.txt\" \"awesome\" \"excellent\"")
@@ -267,9 +850,29 @@ func printHelp() {
 	fmt.Println("")
 	fmt.Println("EXAMPLE:")
 	fmt.Println("")
-	fmt.Println("    total-rename --ignore \".git/|dist/\" --binary \".jpg|.jpeg|.png\" \"/Users/jeff/projects/my-app/src/**/*.*\" \"awesome\" \"excellent\"")
+	fmt.Println("    total-rename --ignore \"/dist|*.min.js\" --binary \"*.jpg|*.jpeg|*.png\" \"/Users/jeff/projects/my-app/src/**/*.*\" \"awesome\" \"excellent\"")
 	fmt.Println("")
-	fmt.Println("    Ignore anything that has .git/ or dist/ in it's path completely, and don't inspect")
+	fmt.Println("    Ignore the top-level dist folder and minified scripts completely, and don't inspect")
 	fmt.Println("    the contents of png or jpg files.")
 	fmt.Println("")
+	fmt.Println("EXAMPLE:")
+	fmt.Println("")
+	fmt.Println("    total-rename --map renames.yml \"**/*.*\"")
+	fmt.Println("")
+	fmt.Println("    Rename every pair in renames.yml in a single pass. When needles overlap,")
+	fmt.Println("    such as \"space\" and \"spaceMember\", the longest match wins.")
+	fmt.Println("")
+	fmt.Println("EXAMPLE:")
+	fmt.Println("")
+	fmt.Println("    total-rename --plural --irregular \"cactus:cacti\" \"**/*.*\" \"category\" \"cactus\"")
+	fmt.Println("")
+	fmt.Println("    Rename \"category\" to \"cactus\" and \"categories\" to \"cacti\", in every casing.")
+	fmt.Println("")
+	fmt.Println("EXAMPLE:")
+	fmt.Println("")
+	fmt.Println("    total-rename --regex \"**/*.*\" \"v(\\d+)space\" \"v${1}Board\"")
+	fmt.Println("")
+	fmt.Println("    Rename v1Space to v1Board, V2SPACE to V2BOARD, and so on. The casing")
+	fmt.Println("    of each match is applied to the replacement.")
+	fmt.Println("")
 }
@@ -0,0 +1,177 @@
+package mapping
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeffijoe/total-rename/casing"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of a mapping file.
+type Format uint8
+
+// Mapping file formats
+const (
+	FormatLines = Format(iota)
+	FormatJSON
+	FormatYAML
+)
+
+// Pairs is a list of needle/replacement pairs.
+type Pairs []Pair
+
+// Pair is a needle and the string to replace it with.
+type Pair struct {
+	Needle      string
+	Replacement string
+}
+
+// Load reads the pairs from a mapping file. The format is
+// determined by the file extension.
+func Load(filePath string) (Pairs, error) {
+	f, err := os.Open(filepath.FromSlash(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, FormatFromPath(filePath))
+}
+
+// FormatFromPath determines the mapping format from a file path.
+func FormatFromPath(filePath string) Format {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return FormatJSON
+	case ".yml", ".yaml":
+		return FormatYAML
+	}
+	return FormatLines
+}
+
+// Parse reads the pairs from r in the specified format.
+func Parse(r io.Reader, format Format) (Pairs, error) {
+	var pairs Pairs
+	var err error
+	switch format {
+	case FormatJSON:
+		pairs, err = parseJSON(r)
+	case FormatYAML:
+		pairs, err = parseYAML(r)
+	default:
+		pairs, err = parseLines(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = pairs.Validate(); err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// Needles returns the needle of every pair.
+func (pairs Pairs) Needles() []string {
+	result := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		result = append(result, p.Needle)
+	}
+	return result
+}
+
+// Validate checks that there are pairs, and that every needle is
+// given once and has words to determine the casings of.
+func (pairs Pairs) Validate() error {
+	if len(pairs) == 0 {
+		return fmt.Errorf("mapping contains no pairs")
+	}
+	seen := map[string]struct{}{}
+	for _, p := range pairs {
+		if p.Needle == "" {
+			return fmt.Errorf("mapping contains an empty needle")
+		}
+		if len(casing.Words(p.Needle)) == 0 {
+			return fmt.Errorf("needle %q has no letters or digits", p.Needle)
+		}
+		if _, ok := seen[p.Needle]; ok {
+			return fmt.Errorf("mapping contains %q more than once", p.Needle)
+		}
+		seen[p.Needle] = struct{}{}
+	}
+	return nil
+}
+
+// parseLines parses one "<needle> <replacement>" pair per line.
+// Blank lines and lines starting with # are skipped.
+func parseLines(r io.Reader) (Pairs, error) {
+	result := Pairs{}
+	s := bufio.NewScanner(r)
+	lineNum := 0
+	for s.Scan() {
+		lineNum = lineNum + 1
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"<needle> <replacement>\", got %q", lineNum, line)
+		}
+		result = append(result, Pair{fields[0], fields[1]})
+	}
+	return result, s.Err()
+}
+
+// parseJSON parses a JSON object of needles to replacements,
+// preserving the order they are written in.
+func parseJSON(r io.Reader) (Pairs, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected a JSON object of needles to replacements")
+	}
+	result := Pairs{}
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		needle := tok.(string)
+		var replacement string
+		if err = dec.Decode(&replacement); err != nil {
+			return nil, fmt.Errorf("replacement for %q: %v", needle, err)
+		}
+		result = append(result, Pair{needle, replacement})
+	}
+	return result, nil
+}
+
+// parseYAML parses a YAML mapping of needles to replacements,
+// preserving the order they are written in.
+func parseYAML(r io.Reader) (Pairs, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a YAML mapping of needles to replacements")
+	}
+	content := doc.Content[0].Content
+	result := Pairs{}
+	for i := 0; i+1 < len(content); i = i + 2 {
+		key, value := content[i], content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: replacement for %q must be a string", value.Line, key.Value)
+		}
+		result = append(result, Pair{key.Value, value.Value})
+	}
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package mapping_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jeffijoe/total-rename/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	expected := mapping.Pairs{
+		mapping.Pair{Needle: "space", Replacement: "board"},
+		mapping.Pair{Needle: "member", Replacement: "participant"},
+		mapping.Pair{Needle: "owner", Replacement: "admin"},
+	}
+	tests := []struct {
+		name    string
+		format  mapping.Format
+		content string
+	}{
+		{
+			name:    "lines",
+			format:  mapping.FormatLines,
+			content: "# domain rename\nspace board\n\n  member   participant\nowner admin\n",
+		},
+		{
+			name:    "json",
+			format:  mapping.FormatJSON,
+			content: `{"space": "board", "member": "participant", "owner": "admin"}`,
+		},
+		{
+			name:    "yaml",
+			format:  mapping.FormatYAML,
+			content: "space: board\nmember: participant\nowner: admin\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs, err := mapping.Parse(strings.NewReader(tt.content), tt.format)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, pairs)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  mapping.Format
+		content string
+	}{
+		{name: "too many fields", format: mapping.FormatLines, content: "space board stuff"},
+		{name: "empty", format: mapping.FormatLines, content: "# nothing here\n"},
+		{name: "duplicate", format: mapping.FormatLines, content: "space board\nspace room"},
+		{name: "no words", format: mapping.FormatLines, content: "space board\n-_ room"},
+		{name: "json array", format: mapping.FormatJSON, content: `["space", "board"]`},
+		{name: "yaml nested", format: mapping.FormatYAML, content: "space:\n  - board\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mapping.Parse(strings.NewReader(tt.content), tt.format)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	assert.Equal(t, mapping.FormatJSON, mapping.FormatFromPath("renames.JSON"))
+	assert.Equal(t, mapping.FormatYAML, mapping.FormatFromPath("renames.yml"))
+	assert.Equal(t, mapping.FormatYAML, mapping.FormatFromPath("renames.yaml"))
+	assert.Equal(t, mapping.FormatLines, mapping.FormatFromPath("renames.txt"))
+}
@@ -0,0 +1,231 @@
+package patch
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ContextLines is the number of unchanged lines shown around changes.
+const ContextLines = 3
+
+type editKind uint8
+
+const (
+	editEqual = editKind(iota)
+	editDelete
+	editInsert
+)
+
+// edit is a single line of a diff.
+type edit struct {
+	kind editKind
+	line string
+}
+
+// hunk is a group of edits close enough to each other
+// to share their context lines.
+type hunk struct {
+	oldStart int
+	oldLines int
+	newStart int
+	newLines int
+	edits    []edit
+}
+
+// splitLines splits s into lines, keeping their line endings.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes the shortest edit script turning a into b,
+// using Myers' algorithm.
+func diffLines(a, b []string) []edit {
+	// Common prefixes and suffixes are cheap to skip, and most files
+	// only change a few lines.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix = prefix + 1
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix = suffix + 1
+	}
+
+	result := make([]edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		result = append(result, edit{editEqual, line})
+	}
+	result = append(result, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		result = append(result, edit{editEqual, line})
+	}
+	return result
+}
+
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	trace := [][]int{}
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k = k + 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the trace of myers back from the end
+// to find the edits that were taken.
+func backtrack(a, b []string, trace [][]int, offset int) []edit {
+	x, y := len(a), len(b)
+	reversed := []edit{}
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, edit{editEqual, a[x-1]})
+			x, y = x-1, y-1
+		}
+		if x == prevX {
+			reversed = append(reversed, edit{editInsert, b[y-1]})
+		} else {
+			reversed = append(reversed, edit{editDelete, a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, edit{editEqual, a[x-1]})
+		x, y = x-1, y-1
+	}
+
+	result := make([]edit, len(reversed))
+	for i, e := range reversed {
+		result[len(reversed)-1-i] = e
+	}
+	return result
+}
+
+// hunks groups the edits into hunks with the specified number of context lines.
+func hunks(edits []edit, context int) []*hunk {
+	result := []*hunk{}
+	var current *hunk
+	oldLine, newLine := 0, 0
+	// lastChange is the index of the last edit that was not equal.
+	lastChange := -1
+	for i, e := range edits {
+		if e.kind != editEqual {
+			if current == nil || i-lastChange > 2*context {
+				start := i - context
+				if start < 0 {
+					start = 0
+				}
+				if current != nil {
+					current.edits = append(current.edits, edits[lastChange+1:lastChange+1+context]...)
+				}
+				current = &hunk{
+					oldStart: oldLine - (i - start),
+					newStart: newLine - (i - start),
+					edits:    append([]edit{}, edits[start:i]...),
+				}
+				result = append(result, current)
+			} else {
+				current.edits = append(current.edits, edits[lastChange+1:i]...)
+			}
+			current.edits = append(current.edits, e)
+			lastChange = i
+		}
+		if e.kind != editInsert {
+			oldLine = oldLine + 1
+		}
+		if e.kind != editDelete {
+			newLine = newLine + 1
+		}
+	}
+	if current != nil {
+		end := lastChange + 1 + context
+		if end > len(edits) {
+			end = len(edits)
+		}
+		current.edits = append(current.edits, edits[lastChange+1:end]...)
+	}
+
+	for _, h := range result {
+		for _, e := range h.edits {
+			if e.kind != editInsert {
+				h.oldLines = h.oldLines + 1
+			}
+			if e.kind != editDelete {
+				h.newLines = h.newLines + 1
+			}
+		}
+	}
+	return result
+}
+
+// writeHunks writes the hunks in unified diff format.
+func writeHunks(w io.Writer, hs []*hunk) error {
+	for _, h := range hs {
+		if _, err := fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(h.oldStart, h.oldLines), hunkRange(h.newStart, h.newLines)); err != nil {
+			return err
+		}
+		for _, e := range h.edits {
+			prefix := " "
+			switch e.kind {
+			case editDelete:
+				prefix = "-"
+			case editInsert:
+				prefix = "+"
+			}
+			line := prefix + e.line
+			if !strings.HasSuffix(line, "\n") {
+				line = line + "\n\\ No newline at end of file\n"
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hunkRange formats the 0-based start and the length of a hunk
+// the way unified diffs do: 1-based, and pointing at the line
+// before the hunk when it is empty.
+func hunkRange(start, lines int) string {
+	if lines == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if lines == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, lines)
+}
@@ -0,0 +1,179 @@
+package patch
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeffijoe/total-rename/util"
+)
+
+// Recorder records renames and content replacements instead of making
+// them, so they can be written as a patch that git apply accepts.
+// Its Rename and ReplaceFile methods match replacer.RenameFunc and
+// replacer.ReplaceFileFunc.
+type Recorder struct {
+	root     string
+	renames  []rename
+	contents map[string]string
+}
+
+type rename struct {
+	oldPath string
+	newPath string
+}
+
+// file is a file changed by the recorded changes.
+type file struct {
+	oldPath    string
+	newPath    string
+	oldContent string
+	newContent string
+}
+
+// NewRecorder creates a recorder writing paths relative to root.
+func NewRecorder(root string) *Recorder {
+	return &Recorder{
+		root:     root,
+		renames:  []rename{},
+		contents: map[string]string{},
+	}
+}
+
+// Rename records renaming oldPath to newPath.
+func (r *Recorder) Rename(oldPath, newPath string) error {
+	r.renames = append(r.renames, rename{oldPath, newPath})
+	return nil
+}
+
+// ReplaceFile records replacing the contents of the file.
+func (r *Recorder) ReplaceFile(filePath, newContent string) error {
+	r.contents[filePath] = newContent
+	return nil
+}
+
+// WriteTo writes the recorded changes as a git style patch. Content changes
+// are written as unified diffs and renames as rename headers. Git has no
+// notion of folders, so a renamed folder is written as a rename of every
+// file in it.
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	files, err := r.files()
+	if err != nil {
+		return 0, err
+	}
+	cw := &countingWriter{w: w}
+	for _, f := range files {
+		if err := r.writeFile(cw, f); err != nil {
+			return cw.n, err
+		}
+	}
+	return cw.n, nil
+}
+
+// files returns every file that is changed, ordered by its original path.
+// Renames and replacements are recorded before they would be applied, so
+// every recorded path is a path as it is on disk now.
+func (r *Recorder) files() ([]*file, error) {
+	paths := map[string]bool{}
+	for p := range r.contents {
+		paths[p] = true
+	}
+	for _, rn := range r.renames {
+		err := filepath.Walk(rn.oldPath, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !fi.IsDir() {
+				paths[p] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]*file, 0, len(paths))
+	for p := range paths {
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		f := &file{
+			oldPath:    p,
+			newPath:    p,
+			oldContent: string(content),
+			newContent: string(content),
+		}
+		if newContent, ok := r.contents[p]; ok {
+			f.newContent = newContent
+		}
+		for _, rn := range r.renames {
+			f.newPath, _ = util.RebasePath(f.newPath, rn.oldPath, rn.newPath)
+		}
+		if f.oldPath != f.newPath || f.oldContent != f.newContent {
+			result = append(result, f)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].oldPath < result[j].oldPath
+	})
+	return result, nil
+}
+
+func (r *Recorder) writeFile(w io.Writer, f *file) error {
+	oldPath := r.relative(f.oldPath)
+	newPath := r.relative(f.newPath)
+	var edits []edit
+	if f.oldContent != f.newContent {
+		edits = diffLines(splitLines(f.oldContent), splitLines(f.newContent))
+	}
+	header := fmt.Sprintf("diff --git a/%s b/%s\n", oldPath, newPath)
+	if oldPath != newPath {
+		header = header + fmt.Sprintf("similarity index %d%%\nrename from %s\nrename to %s\n", similarity(edits), oldPath, newPath)
+	}
+	if f.oldContent != f.newContent {
+		header = header + fmt.Sprintf("--- a/%s\n+++ b/%s\n", oldPath, newPath)
+	}
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	return writeHunks(w, hunks(edits, ContextLines))
+}
+
+// relative returns the slash separated path relative to the root.
+func (r *Recorder) relative(p string) string {
+	if rel, err := filepath.Rel(r.root, p); err == nil {
+		p = rel
+	}
+	return strings.TrimPrefix(filepath.ToSlash(p), "/")
+}
+
+// similarity is the percentage of lines that are unchanged.
+func similarity(edits []edit) int {
+	if len(edits) == 0 {
+		return 100
+	}
+	equal := 0
+	for _, e := range edits {
+		if e.kind == editEqual {
+			equal = equal + 1
+		}
+	}
+	return equal * 100 / len(edits)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n = cw.n + int64(n)
+	return n, err
+}
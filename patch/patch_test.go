@@ -0,0 +1,113 @@
+package patch
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "single change",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			want: "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "insertion at the start",
+			old:  "a\n",
+			new:  "x\na\n",
+			want: "@@ -1 +1,2 @@\n+x\n a\n",
+		},
+		{
+			name: "deletion",
+			old:  "a\nb\n",
+			new:  "a\n",
+			want: "@@ -1,2 +1 @@\n a\n-b\n",
+		},
+		{
+			name: "separate hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:  "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			name: "shared context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n",
+			new:  "one\n2\n3\n4\n5\n6\nseven\n",
+			want: "@@ -1,7 +1,7 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n-7\n+seven\n",
+		},
+		{
+			name: "no newline at end of file",
+			old:  "a",
+			new:  "b",
+			want: "@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+b\n\\ No newline at end of file\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			edits := diffLines(splitLines(tt.old), splitLines(tt.new))
+			require.NoError(t, writeHunks(buf, hunks(edits, ContextLines)))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "spaces"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "spaces", "space.js"), []byte("const space = 1\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "spaces", "logo.png"), []byte("\x89PNG"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "index.js"), []byte("import 'spaces/space'\n"), 0644))
+
+	r := NewRecorder(tempDir)
+	require.NoError(t, r.ReplaceFile(filepath.Join(tempDir, "index.js"), "import 'boards/board'\n"))
+	require.NoError(t, r.ReplaceFile(filepath.Join(tempDir, "spaces", "space.js"), "const board = 1\n"))
+	require.NoError(t, r.Rename(filepath.Join(tempDir, "spaces", "space.js"), filepath.Join(tempDir, "spaces", "board.js")))
+	require.NoError(t, r.Rename(filepath.Join(tempDir, "spaces"), filepath.Join(tempDir, "boards")))
+
+	buf := &bytes.Buffer{}
+	n, err := r.WriteTo(buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	assert.Equal(t, strings.Join([]string{
+		"diff --git a/index.js b/index.js",
+		"--- a/index.js",
+		"+++ b/index.js",
+		"@@ -1 +1 @@",
+		"-import 'spaces/space'",
+		"+import 'boards/board'",
+		"diff --git a/spaces/logo.png b/boards/logo.png",
+		"similarity index 100%",
+		"rename from spaces/logo.png",
+		"rename to boards/logo.png",
+		"diff --git a/spaces/space.js b/boards/board.js",
+		"similarity index 0%",
+		"rename from spaces/space.js",
+		"rename to boards/board.js",
+		"--- a/spaces/space.js",
+		"+++ b/boards/board.js",
+		"@@ -1 +1 @@",
+		"-const space = 1",
+		"+const board = 1",
+		"",
+	}, "\n"), buf.String())
+
+	_, err = os.Stat(filepath.Join(tempDir, "spaces", "space.js"))
+	assert.NoError(t, err, "nothing should be renamed")
+}
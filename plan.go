@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jeffijoe/total-rename/plan"
+	"github.com/jeffijoe/total-rename/replacer"
+	"github.com/jeffijoe/total-rename/scanner"
+	"github.com/jeffijoe/total-rename/util"
+)
+
+// savePlan writes the groups and replacements to a plan file,
+// to be applied later with the apply subcommand.
+func savePlan(planFile string, groups scanner.OccurenceGroups, replacements replacer.Replacements) {
+	p, err := plan.New(util.GetWD(), groups, replacements)
+	if err == nil {
+		err = p.Save(planFile)
+	}
+	if err != nil {
+		fmt.Printf("Could not write the plan: %v\n", err)
+		return
+	}
+	count := 0
+	for _, g := range groups {
+		count = count + len(g.Occurences)
+	}
+	fmt.Printf("Done! Planned renaming %d occurences in %s\n", count, planFile)
+	fmt.Printf("Run \"total-rename apply %s\" to apply it.\n", planFile)
+}
+
+// applyPlan runs the apply subcommand, which applies a plan file unless
+// any of the files in it changed since the plan was made.
+func applyPlan(args []string, options *applyOptions) {
+	if len(args) < 1 {
+		fmt.Println("Not enough arguments, expects 1: apply <plan.json>")
+		return
+	}
+	p, err := plan.Load(args[0])
+	if err != nil {
+		fmt.Printf("Could not read the plan: %v\n", err)
+		return
+	}
+	if err := p.Verify(util.GetWD()); err != nil {
+		if changed, ok := err.(*plan.ChangedError); ok {
+			fmt.Println("Refusing to apply the plan, these files changed since it was made:")
+			for _, path := range changed.Paths {
+				fmt.Println("    " + path)
+			}
+			return
+		}
+		panic(err)
+	}
+	applyGroups(p.OccurenceGroups(util.GetWD()), p.ReplacerReplacements(), options)
+}
@@ -0,0 +1,261 @@
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/inflection"
+	"github.com/jeffijoe/total-rename/replacer"
+	"github.com/jeffijoe/total-rename/scanner"
+)
+
+// Version is the version of the plan file format.
+const Version = 1
+
+// Plan is a set of occurence groups to rename and the replacements to rename
+// them with, serialized so they can be reviewed and applied later.
+// Paths are relative to the working directory, so a plan can be applied
+// in another checkout of the same files.
+type Plan struct {
+	Version      int                     `json:"version"`
+	Replacements map[string]*Replacement `json:"replacements"`
+	Groups       []*Group                `json:"groups"`
+}
+
+// Replacement is a serialized replacer.Replacement.
+type Replacement struct {
+	Value    string    `json:"value"`
+	Singular []Variant `json:"singular"`
+	Plural   []Variant `json:"plural"`
+}
+
+// Variant is a serialized casing.Variant.
+type Variant struct {
+	Casing casing.Casing `json:"casing"`
+	Value  string        `json:"value"`
+}
+
+// Group is a serialized scanner.OccurenceGroup. Content groups have the
+// hash of the file contents, so changed files can be detected. The start
+// indexes of path occurences are relative to Path rather than the full path.
+type Group struct {
+	Type       string       `json:"type"`
+	Path       string       `json:"path"`
+	Hash       string       `json:"hash,omitempty"`
+	Occurences []*Occurence `json:"occurences"`
+}
+
+// Occurence is a serialized scanner.Occurence. Casing is the casing it
+// is replaced in, which may be one of its casings with an overridden variant.
+type Occurence struct {
+	Needle     string            `json:"needle"`
+	Number     inflection.Number `json:"number"`
+	Casing     casing.Casing     `json:"casing"`
+	Match      string            `json:"match"`
+	StartIndex int               `json:"startIndex"`
+	LineNumber int               `json:"lineNumber"`
+	Captures   map[string]string `json:"captures,omitempty"`
+	Override   *string           `json:"override,omitempty"`
+}
+
+// Group types
+const (
+	GroupTypeContent = "content"
+	GroupTypePath    = "path"
+)
+
+// ChangedError is returned when files have changed since the plan was made.
+type ChangedError struct {
+	Paths []string
+}
+
+func (e *ChangedError) Error() string {
+	return fmt.Sprintf("%d files changed since the plan was made", len(e.Paths))
+}
+
+// New creates a plan for the occurence groups, with paths relative to root.
+// The groups are kept in the order they are applied in.
+func New(root string, groups scanner.OccurenceGroups, replacements replacer.Replacements) (*Plan, error) {
+	sorted := append(scanner.OccurenceGroups{}, groups...)
+	sort.Stable(sorted)
+	p := &Plan{
+		Version:      Version,
+		Replacements: map[string]*Replacement{},
+		Groups:       make([]*Group, 0, len(groups)),
+	}
+	for needle, r := range replacements {
+		p.Replacements[needle] = &Replacement{
+			Value:    r.Value,
+			Singular: newVariants(r.Singular),
+			Plural:   newVariants(r.Plural),
+		}
+	}
+	for _, group := range sorted {
+		g, err := newGroup(root, group, replacements)
+		if err != nil {
+			return nil, err
+		}
+		p.Groups = append(p.Groups, g)
+	}
+	return p, nil
+}
+
+func newVariants(variants casing.Variants) []Variant {
+	result := make([]Variant, 0, len(variants))
+	for _, v := range variants {
+		result = append(result, Variant{v.Casing, v.Value})
+	}
+	return result
+}
+
+func newGroup(root string, group *scanner.OccurenceGroup, replacements replacer.Replacements) (*Group, error) {
+	rel, err := filepath.Rel(root, group.Path)
+	if err != nil {
+		return nil, err
+	}
+	g := &Group{
+		Type:       GroupTypePath,
+		Path:       filepath.ToSlash(rel),
+		Occurences: make([]*Occurence, 0, len(group.Occurences)),
+	}
+	offset := utf8.RuneCountInString(group.Path) - utf8.RuneCountInString(rel)
+	if group.Type == scanner.OccurenceGroupTypeContent {
+		g.Type = GroupTypeContent
+		offset = 0
+		if g.Hash, err = hashFile(group.Path); err != nil {
+			return nil, err
+		}
+	}
+	for _, oc := range group.Occurences {
+		g.Occurences = append(g.Occurences, &Occurence{
+			Needle:     oc.Needle,
+			Number:     oc.Number,
+			Casing:     replacements.Casing(oc),
+			Match:      oc.Match,
+			StartIndex: oc.StartIndex - offset,
+			LineNumber: oc.LineNumber,
+			Captures:   oc.Captures,
+			Override:   oc.Override,
+		})
+	}
+	return g, nil
+}
+
+// Load reads a plan file.
+func Load(filePath string) (*Plan, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	p := &Plan{}
+	if err := json.Unmarshal(content, p); err != nil {
+		return nil, fmt.Errorf("invalid plan %s: %v", filePath, err)
+	}
+	if p.Version != Version {
+		return nil, fmt.Errorf("plan %s has version %d, expected %d", filePath, p.Version, Version)
+	}
+	return p, nil
+}
+
+// Save writes the plan to a file.
+func (p *Plan) Save(filePath string) error {
+	content, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, append(content, '\n'), 0644)
+}
+
+// Verify checks that every file in the plan still exists, and that the
+// contents of the files to replace the contents of have not changed.
+// Returns a *ChangedError listing the files that have.
+func (p *Plan) Verify(root string) error {
+	changed := []string{}
+	for _, g := range p.Groups {
+		path := filepath.Join(root, filepath.FromSlash(g.Path))
+		if g.Type == GroupTypeContent {
+			hash, err := hashFile(path)
+			if err != nil || hash != g.Hash {
+				changed = append(changed, path)
+			}
+			continue
+		}
+		if _, err := os.Lstat(path); err != nil {
+			changed = append(changed, path)
+		}
+	}
+	if len(changed) > 0 {
+		return &ChangedError{Paths: changed}
+	}
+	return nil
+}
+
+// OccurenceGroups returns the occurence groups of the plan, with paths
+// relative to root.
+func (p *Plan) OccurenceGroups(root string) scanner.OccurenceGroups {
+	result := make(scanner.OccurenceGroups, 0, len(p.Groups))
+	for _, g := range p.Groups {
+		group := &scanner.OccurenceGroup{
+			Path:       filepath.Join(root, filepath.FromSlash(g.Path)),
+			Type:       scanner.OccurenceGroupTypePath,
+			Occurences: make(scanner.Occurences, 0, len(g.Occurences)),
+		}
+		offset := utf8.RuneCountInString(group.Path) - utf8.RuneCountInString(filepath.FromSlash(g.Path))
+		if g.Type == GroupTypeContent {
+			group.Type = scanner.OccurenceGroupTypeContent
+			offset = 0
+		}
+		for _, oc := range g.Occurences {
+			group.Occurences = append(group.Occurences, &scanner.Occurence{
+				Needle:     oc.Needle,
+				Number:     oc.Number,
+				Casing:     oc.Casing,
+				Match:      oc.Match,
+				StartIndex: oc.StartIndex + offset,
+				LineNumber: oc.LineNumber,
+				Captures:   oc.Captures,
+				Override:   oc.Override,
+			})
+		}
+		result = append(result, group)
+	}
+	return result
+}
+
+// ReplacerReplacements returns the replacements of the plan.
+func (p *Plan) ReplacerReplacements() replacer.Replacements {
+	result := replacer.Replacements{}
+	for needle, r := range p.Replacements {
+		result[needle] = &replacer.Replacement{
+			Value:    r.Value,
+			Singular: toVariants(r.Singular),
+			Plural:   toVariants(r.Plural),
+		}
+	}
+	return result
+}
+
+func toVariants(variants []Variant) casing.Variants {
+	result := make(casing.Variants, 0, len(variants))
+	for _, v := range variants {
+		result = append(result, casing.Variant{Casing: v.Casing, Value: v.Value})
+	}
+	return result
+}
+
+func hashFile(filePath string) (string, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
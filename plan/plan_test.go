@@ -0,0 +1,106 @@
+package plan
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/lister"
+	"github.com/jeffijoe/total-rename/mapping"
+	"github.com/jeffijoe/total-rename/replacer"
+	"github.com/jeffijoe/total-rename/scanner"
+	"github.com/jeffijoe/total-rename/simplematch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setup creates a folder with a "spaces" folder holding a "space.js" file,
+// and scans it for "space".
+func setup(t *testing.T) (string, scanner.OccurenceGroups) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "spaces"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "spaces", "space.js"), []byte("const space = 1\n"), 0644))
+
+	nodes := lister.FileNodes{
+		&lister.FileNode{Path: filepath.Join(tempDir, "spaces", "space.js"), Type: lister.NodeTypeFile},
+		&lister.FileNode{Path: filepath.Join(tempDir, "spaces"), Type: lister.NodeTypeDir},
+	}
+	groups, _, err := scanner.ScanFileNodes(nodes, scanner.NewNeedles("space"), simplematch.NewMatcher("", ""), simplematch.NewMatcher("", ""))
+	require.NoError(t, err)
+	return tempDir, groups
+}
+
+func TestPlan_SaveAndApplyElsewhere(t *testing.T) {
+	tempDir, groups := setup(t)
+	defer os.RemoveAll(tempDir)
+
+	planFile := filepath.Join(tempDir, "plan.json")
+	p, err := New(tempDir, groups, replacer.NewReplacements(mapping.Pairs{mapping.Pair{Needle: "space", Replacement: "board"}}))
+	require.NoError(t, err)
+	require.NoError(t, p.Save(planFile))
+	loaded, err := Load(planFile)
+	require.NoError(t, err)
+	assert.Equal(t, p, loaded)
+
+	// Applying the plan in a copy with a longer path
+	// must still find the occurences in the paths.
+	copyDir := filepath.Join(tempDir, "checkout")
+	require.NoError(t, os.MkdirAll(filepath.Join(copyDir, "spaces"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(copyDir, "spaces", "space.js"), []byte("const space = 1\n"), 0644))
+	require.NoError(t, loaded.Verify(copyDir))
+	_, err = replacer.TotalRename(loaded.OccurenceGroups(copyDir), loaded.ReplacerReplacements(), os.Rename, replacer.ReplaceFileContent)
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(filepath.Join(copyDir, "boards", "board.js"))
+	require.NoError(t, err)
+	assert.Equal(t, "const board = 1\n", string(content))
+}
+
+func TestPlan_OverriddenCasing(t *testing.T) {
+	tempDir, groups := setup(t)
+	defer os.RemoveAll(tempDir)
+
+	replacements := replacer.NewReplacements(mapping.Pairs{mapping.Pair{Needle: "space", Replacement: "board"}})
+	require.NoError(t, replacements.OverrideCasings(replacer.CasingOverrides{"space": {casing.LowerCase: "brd"}}, nil))
+	p, err := New(tempDir, groups, replacements)
+	require.NoError(t, err)
+	assert.EqualValues(t, casing.LowerCase, p.Groups[0].Occurences[0].Casing, "the casing it is replaced in is saved")
+
+	planFile := filepath.Join(tempDir, "plan.json")
+	require.NoError(t, p.Save(planFile))
+	loaded, err := Load(planFile)
+	require.NoError(t, err)
+	_, err = replacer.TotalRename(loaded.OccurenceGroups(tempDir), loaded.ReplacerReplacements(), os.Rename, replacer.ReplaceFileContent)
+	require.NoError(t, err)
+	content, err := ioutil.ReadFile(filepath.Join(tempDir, "brds", "brd.js"))
+	require.NoError(t, err)
+	assert.Equal(t, "const brd = 1\n", string(content))
+}
+
+func TestPlan_Verify(t *testing.T) {
+	tempDir, groups := setup(t)
+	defer os.RemoveAll(tempDir)
+
+	p, err := New(tempDir, groups, replacer.NewReplacements(mapping.Pairs{mapping.Pair{Needle: "space", Replacement: "board"}}))
+	require.NoError(t, err)
+	assert.NoError(t, p.Verify(tempDir))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "spaces", "space.js"), []byte("const space = 2\n"), 0644))
+	err = p.Verify(tempDir)
+	require.IsType(t, &ChangedError{}, err)
+	assert.Equal(t, []string{filepath.Join(tempDir, "spaces", "space.js")}, err.(*ChangedError).Paths)
+}
+
+func TestLoad_UnknownVersion(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	planFile := filepath.Join(tempDir, "plan.json")
+	require.NoError(t, ioutil.WriteFile(planFile, []byte(`{"version": 2}`), 0644))
+	_, err = Load(planFile)
+	assert.Error(t, err)
+}
@@ -0,0 +1,331 @@
+package replacer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CollisionPolicy determines how rename collisions are resolved.
+type CollisionPolicy uint8
+
+// Collision policies
+const (
+	// CollisionAbort refuses to apply any change when there are collisions.
+	CollisionAbort = CollisionPolicy(iota)
+	// CollisionSkip leaves colliding paths as they are.
+	CollisionSkip
+	// CollisionMerge moves the contents of a folder into the folder it
+	// collides with. Colliding files are not resolved.
+	CollisionMerge
+	// CollisionSuffix adds a numeric suffix to the colliding name.
+	CollisionSuffix
+)
+
+var collisionPolicyNames = []string{"abort", "skip", "merge", "suffix"}
+
+// ParseCollisionPolicy parses the name of a collision policy.
+func ParseCollisionPolicy(s string) (CollisionPolicy, error) {
+	for i, name := range collisionPolicyNames {
+		if strings.EqualFold(s, name) {
+			return CollisionPolicy(i), nil
+		}
+	}
+	return CollisionAbort, fmt.Errorf("unknown collision policy %q, expected one of %s", s, strings.Join(collisionPolicyNames, ", "))
+}
+
+func (p CollisionPolicy) String() string {
+	return collisionPolicyNames[p]
+}
+
+// Collision is a rename whose target is already taken, either by an
+// existing file or folder, or by another renamed one (With).
+type Collision struct {
+	Change *Change
+	Target string
+	With   string
+	// Err is why the collision could not be merged.
+	Err error
+	// Resolution is the policy that resolved the collision,
+	// CollisionAbort if it is unresolved.
+	Resolution CollisionPolicy
+}
+
+func (c *Collision) String() string {
+	reason := c.Target + " already exists"
+	if c.With != "" {
+		reason = c.With + " is renamed to it as well"
+	}
+	switch c.Resolution {
+	case CollisionSkip:
+		return fmt.Sprintf("%s: %s, skipped", c.Change.Path, reason)
+	case CollisionMerge:
+		return fmt.Sprintf("%s: %s, merging", c.Change.Path, reason)
+	case CollisionSuffix:
+		return fmt.Sprintf("%s: %s, renaming to %s instead", c.Change.Path, reason, c.Change.NewPath)
+	}
+	if c.Err != nil {
+		return fmt.Sprintf("%s: %s, %v", c.Change.Path, reason, c.Err)
+	}
+	return fmt.Sprintf("%s: %s", c.Change.Path, reason)
+}
+
+// CollisionError is returned when collisions could not be resolved.
+type CollisionError struct {
+	Collisions []*Collision
+}
+
+func (e *CollisionError) Error() string {
+	return fmt.Sprintf("%d renames collide with existing or other renamed paths", len(e.Collisions))
+}
+
+// ResolveCollisions computes the target of every rename in the order they
+// are applied, and finds the ones that would collide with an existing path
+// or the target of an earlier rename. Collisions are resolved using the
+// policy, and the changes to apply are returned along with every collision
+// that was found. Nothing on disk is changed. A *CollisionError is returned
+// when collisions remain unresolved.
+func ResolveCollisions(changes Changes, policy CollisionPolicy) (Changes, []*Collision, error) {
+	result := make(Changes, 0, len(changes))
+	collisions := []*Collision{}
+	unresolved := []*Collision{}
+	plan := newPlan()
+	for _, change := range changes {
+		if change.Type != ChangeTypeRename {
+			result = append(result, change)
+			continue
+		}
+		with, taken := plan.taken(change.Path, change.NewPath)
+		if !taken {
+			plan.rename(change.Path, change.NewPath)
+			result = append(result, change)
+			continue
+		}
+
+		collision := &Collision{Change: change, Target: change.NewPath, With: with}
+		collisions = append(collisions, collision)
+		switch policy {
+		case CollisionSkip:
+			collision.Resolution = CollisionSkip
+			continue
+		case CollisionSuffix:
+			change.NewPath = plan.suffixed(change.Path, change.NewPath)
+			collision.Resolution = CollisionSuffix
+		case CollisionMerge:
+			if err := plan.canMerge(change.Path, change.NewPath); err != nil {
+				collision.Err = err
+				unresolved = append(unresolved, collision)
+				continue
+			}
+			change.Merge = true
+			collision.Resolution = CollisionMerge
+		default:
+			unresolved = append(unresolved, collision)
+			continue
+		}
+		plan.rename(change.Path, change.NewPath)
+		result = append(result, change)
+	}
+	if len(unresolved) > 0 {
+		return nil, collisions, &CollisionError{Collisions: unresolved}
+	}
+	return result, collisions, nil
+}
+
+// plan tracks the renames planned so far, so paths can be
+// checked as they will be by the time a rename is applied.
+type plan struct {
+	// moved maps the target of each planned rename to its source.
+	moved map[string]string
+	// gone holds the sources of the planned renames.
+	gone map[string]bool
+}
+
+func newPlan() *plan {
+	return &plan{
+		moved: map[string]string{},
+		gone:  map[string]bool{},
+	}
+}
+
+func (p *plan) rename(oldPath, newPath string) {
+	p.moved[newPath] = oldPath
+	p.gone[oldPath] = true
+	delete(p.gone, newPath)
+}
+
+// taken checks whether target is taken by the time source is renamed to
+// it. When taken by the target of an earlier rename, its source is returned.
+func (p *plan) taken(source, target string) (string, bool) {
+	if with, ok := p.moved[target]; ok {
+		return with, true
+	}
+	if p.gone[target] {
+		return "", false
+	}
+	existing, err := os.Lstat(target)
+	if err != nil {
+		return "", false
+	}
+	// Renames that only change the casing find the
+	// source itself on case-insensitive file systems.
+	if renamed, err := os.Lstat(source); err == nil && os.SameFile(existing, renamed) {
+		return "", false
+	}
+	return "", true
+}
+
+// isDir checks whether the path will be a folder, following planned renames.
+func (p *plan) isDir(path string) bool {
+	if source, ok := p.moved[path]; ok {
+		path = source
+	}
+	fi, err := os.Lstat(path)
+	return err == nil && fi.IsDir()
+}
+
+// suffixed returns the first target with a numeric suffix that is not taken,
+// like "board-2.js" for "board.js".
+func (p *plan) suffixed(source, target string) string {
+	dir, base := filepath.Split(target)
+	ext := filepath.Ext(base)
+	if p.isDir(source) {
+		ext = ""
+	}
+	name := strings.TrimSuffix(base, ext)
+	for i := 2; ; i++ {
+		candidate := filepath.Join(dir, name+"-"+strconv.Itoa(i)+ext)
+		if _, taken := p.taken(source, candidate); !taken {
+			return candidate
+		}
+	}
+}
+
+// canMerge checks that the source folder can be merged into the target
+// folder without any of the files in them colliding.
+func (p *plan) canMerge(source, target string) error {
+	if !p.isDir(source) || !p.isDir(target) {
+		return fmt.Errorf("only folders can be merged")
+	}
+	sourceEntries, err := p.entries(source)
+	if err != nil {
+		return err
+	}
+	targetEntries, err := p.entries(target)
+	if err != nil {
+		return err
+	}
+	for name, sourcePath := range sourceEntries {
+		targetPath, ok := targetEntries[name]
+		if !ok {
+			continue
+		}
+		if !p.isDir(sourcePath) || !p.isDir(targetPath) {
+			return fmt.Errorf("%s exists in both %s and %s", name, source, target)
+		}
+		if err := p.canMerge(sourcePath, targetPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entries lists the names in the folder by the time the planned renames
+// are applied, mapped to where they are on disk now.
+func (p *plan) entries(dir string) (map[string]string, error) {
+	real := dir
+	if source, ok := p.moved[dir]; ok {
+		real = source
+	}
+	files, err := ioutil.ReadDir(real)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]string{}
+	for _, fi := range files {
+		path := filepath.Join(real, fi.Name())
+		if !p.gone[path] {
+			result[fi.Name()] = path
+		}
+	}
+	for target, source := range p.moved {
+		if filepath.Dir(target) == dir {
+			result[filepath.Base(target)] = source
+		}
+	}
+	return result, nil
+}
+
+// move is a rename made while merging folders.
+type move struct {
+	from string
+	to   string
+}
+
+// merge moves the contents of the folder at c.Path into the
+// folder at c.NewPath, and removes the then empty folder.
+func (c *Change) merge(rename RenameFunc) error {
+	c.moved = []move{}
+	return mergeDir(c.Path, c.NewPath, rename, &c.moved)
+}
+
+func mergeDir(source, target string, rename RenameFunc, moved *[]move) error {
+	files, err := ioutil.ReadDir(source)
+	if err != nil {
+		return err
+	}
+	for _, fi := range files {
+		from := filepath.Join(source, fi.Name())
+		to := filepath.Join(target, fi.Name())
+		existing, err := os.Lstat(to)
+		if err == nil {
+			if !fi.IsDir() || !existing.IsDir() {
+				return fmt.Errorf("can not merge %s into %s, it already exists", from, to)
+			}
+			if err := mergeDir(from, to, rename, moved); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := rename(from, to); err != nil {
+			return err
+		}
+		*moved = append(*moved, move{from, to})
+	}
+	return removeEmptyDir(source)
+}
+
+// unmerge moves the contents merged into the folder at c.NewPath
+// back to the folder at c.Path.
+func (c *Change) unmerge(rename RenameFunc) error {
+	if err := os.MkdirAll(c.Path, 0755); err != nil {
+		return err
+	}
+	for i := len(c.moved) - 1; i >= 0; i-- {
+		m := c.moved[i]
+		if err := os.MkdirAll(filepath.Dir(m.from), 0755); err != nil {
+			return err
+		}
+		if err := rename(m.to, m.from); err != nil {
+			return err
+		}
+		c.moved = c.moved[:i]
+	}
+	return nil
+}
+
+// removeEmptyDir removes the folder if everything has been moved out of it.
+// It is kept when renames did not actually move anything, as in dry runs.
+func removeEmptyDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		return nil
+	}
+	return os.Remove(dir)
+}
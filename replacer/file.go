@@ -0,0 +1,56 @@
+package replacer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ReplaceFileContent replaces the file contents. The new contents are written
+// to a temporary file in the same folder, which is then renamed over the file,
+// so a crash or a full disk never leaves the file half-written. The mode, owner
+// and extended attributes of the file are carried over where possible.
+func ReplaceFileContent(filePath, newContent string) (err error) {
+	// Replace the file a symlink points to, not the symlink itself.
+	target, err := filepath.EvalSymlinks(filePath)
+	if err != nil {
+		return err
+	}
+	fileInfo, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(target)
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.WriteString(newContent); err != nil {
+		return err
+	}
+	// The owner has to be changed before the mode, as changing
+	// it clears the setuid and setgid bits.
+	copyMetadata(tmp, target, fileInfo)
+	if err = tmp.Chmod(fileInfo.Mode()); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), target); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
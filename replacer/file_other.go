@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package replacer
+
+import (
+	"os"
+)
+
+// copyMetadata is a no-op on platforms without Unix owners and extended attributes.
+func copyMetadata(f *os.File, source string, fileInfo os.FileInfo) {}
+
+// syncDir is a no-op on platforms that can't sync folders.
+func syncDir(dir string) {}
@@ -0,0 +1,56 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package replacer
+
+import (
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyMetadata gives f the owner and extended attributes of the file at
+// source, as far as we are allowed to. Failures are ignored, as only the
+// contents of the file are essential.
+func copyMetadata(f *os.File, source string, fileInfo os.FileInfo) {
+	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+		f.Chown(int(stat.Uid), int(stat.Gid))
+	}
+
+	size, err := unix.Listxattr(source, nil)
+	if err != nil || size <= 0 {
+		return
+	}
+	names := make([]byte, size)
+	size, err = unix.Listxattr(source, names)
+	if err != nil {
+		return
+	}
+	for _, name := range strings.Split(string(names[:size]), "\x00") {
+		if name == "" {
+			continue
+		}
+		size, err := unix.Getxattr(source, name, nil)
+		if err != nil {
+			continue
+		}
+		value := make([]byte, size)
+		size, err = unix.Getxattr(source, name, value)
+		if err != nil {
+			continue
+		}
+		unix.Fsetxattr(int(f.Fd()), name, value[:size], 0)
+	}
+}
+
+// syncDir flushes the folder, so a rename in it survives a crash.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
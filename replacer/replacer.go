@@ -1,14 +1,13 @@
 package replacer
 
 import (
+	"fmt"
 	"strings"
-
-	"io/ioutil"
-	"os"
-
 	"unicode/utf8"
 
 	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/inflection"
+	"github.com/jeffijoe/total-rename/mapping"
 	"github.com/jeffijoe/total-rename/scanner"
 )
 
@@ -23,101 +22,314 @@ type TotalRenameResult struct {
 	OccurencesRenamed int
 }
 
-// TotalRename will rename files and paths.
-func TotalRename(groups scanner.OccurenceGroups, replacement string, rename RenameFunc, replaceFile ReplaceFileFunc) (*TotalRenameResult, error) {
-	renamed := 0
-	replacementVariants := casing.GenerateCasings(replacement)
-	for _, group := range groups {
-		var count int
-		var err error
-		switch group.Type {
-		case scanner.OccurenceGroupTypeContent:
-			count, err = totalRenameFile(group, replacementVariants, replaceFile)
-		case scanner.OccurenceGroupTypePath:
-			count, err = totalRenamePath(group, replacementVariants, rename)
+// Replacements maps each needle to its replacement.
+type Replacements map[string]*Replacement
+
+// Replacement contains the casing variants of a replacement,
+// in singular and plural form. Overridden are the casings of the
+// singular variants that were given explicitly.
+type Replacement struct {
+	Value      string
+	Singular   casing.Variants
+	Plural     casing.Variants
+	Overridden casing.Set
+}
+
+// NewReplacements generates the replacement variants for every pair.
+func NewReplacements(pairs mapping.Pairs) Replacements {
+	result := Replacements{}
+	for _, p := range pairs {
+		variants := casing.GenerateCasings(p.Replacement)
+		result[p.Needle] = &Replacement{
+			Value:    p.Replacement,
+			Singular: variants,
+			Plural:   variants,
+		}
+	}
+	return result
+}
+
+// NewInflectedReplacements generates the singular and plural
+// replacement variants for every pair.
+func NewInflectedReplacements(rules *inflection.Rules, pairs mapping.Pairs) Replacements {
+	result := Replacements{}
+	for _, p := range pairs {
+		result[p.Needle] = &Replacement{
+			Value:    p.Replacement,
+			Singular: casing.GenerateCasings(p.Replacement),
+			Plural:   casing.GenerateCasings(rules.Pluralize(p.Replacement)),
 		}
+	}
+	return result
+}
+
+// CasingOverrides maps needles to the values that replace them in some
+// casings. Overrides for the empty needle apply to the only needle.
+type CasingOverrides map[string]map[casing.Casing]string
+
+// ParseCasingOverrides parses a | separated list of [needle:]casing=value
+// pairs, like "space:upper=BRD|room:title=Hall".
+func ParseCasingOverrides(pairs string) (CasingOverrides, error) {
+	result := CasingOverrides{}
+	for _, pair := range strings.Split(pairs, "|") {
+		needle := ""
+		if idx := strings.Index(pair, ":"); idx >= 0 && idx < strings.Index(pair, "=") {
+			needle, pair = strings.TrimSpace(pair[:idx]), pair[idx+1:]
+		}
+		overrides, err := casing.ParseOverrides(pair)
 		if err != nil {
 			return nil, err
 		}
-		renamed = renamed + count
+		if len(overrides) == 0 {
+			continue
+		}
+		if result[needle] == nil {
+			result[needle] = map[casing.Casing]string{}
+		}
+		for c, value := range overrides {
+			result[needle][c] = value
+		}
 	}
+	return result, nil
+}
 
-	return &TotalRenameResult{
-		OccurencesRenamed: renamed,
-	}, nil
+// OverrideCasings sets the variants of the replacements of the needles in
+// the casings to the specified values, so "SPACE" can become "BRD" while
+// "space" becomes "board". When rules are given, the plural variants are
+// set to the plural of the values.
+func (r Replacements) OverrideCasings(overrides CasingOverrides, rules *inflection.Rules) error {
+	for needle, values := range overrides {
+		replacement := r[needle]
+		if needle == "" && len(r) == 1 {
+			for _, only := range r {
+				replacement = only
+			}
+		}
+		if needle == "" && replacement == nil {
+			return fmt.Errorf("with several needles, say which one a casing is replaced for, like %s:upper=BRD", r.firstNeedle())
+		}
+		if replacement == nil {
+			return fmt.Errorf("%q is not one of the needles", needle)
+		}
+		for c, value := range values {
+			plural := value
+			if rules != nil {
+				plural = rules.Pluralize(value)
+			}
+			replacement.Singular = replacement.Singular.Override(c, value)
+			replacement.Plural = replacement.Plural.Override(c, plural)
+			replacement.Overridden = replacement.Overridden.Add(c)
+		}
+	}
+	return nil
 }
 
-func totalRenameFile(group *scanner.OccurenceGroup, replacement casing.Variants, replaceFile ReplaceFileFunc) (int, error) {
-	contentBytes, err := ioutil.ReadFile(group.Path)
-	if err != nil {
-		return 0, err
+// firstNeedle returns the needle that sorts first.
+func (r Replacements) firstNeedle() string {
+	result := ""
+	for needle := range r {
+		if result == "" || needle < result {
+			result = needle
+		}
 	}
+	return result
+}
 
-	content := string(contentBytes)
-	newContent := ReplaceText(content, group.Occurences, replacement)
-	err = replaceFile(group.Path, newContent)
-	if err != nil {
-		return 0, err
+// Casing returns the casing the occurence is replaced in. That is its
+// own casing, unless the match is written the same in a casing that
+// has an overridden variant, or in one that keeps the words of a
+// replacement of several words apart.
+func (r Replacements) Casing(oc *scanner.Occurence) casing.Casing {
+	replacement := r[oc.Needle]
+	if replacement == nil || oc.Captures != nil {
+		return oc.Casing
+	}
+	if overridden := oc.Casings & replacement.Overridden; overridden != 0 {
+		return overridden.Primary()
 	}
-	return len(group.Occurences), nil
+	if len(casing.Words(replacement.Value)) > 1 {
+		return oc.Casings.KeepWords(oc.Casing)
+	}
+	return oc.Casing
 }
 
-func totalRenamePath(group *scanner.OccurenceGroup, replacement casing.Variants, rename RenameFunc) (int, error) {
-	newPath := ReplaceText(group.Path, group.Occurences, replacement)
-	if err := rename(group.Path, newPath); err != nil {
-		return 0, err
+// Replace returns the string the occurence should be replaced with,
+// which is its override when it has one. Occurences in the Mimic casing
+// get the replacement in the upper and lower case letters of the match.
+func (r Replacements) Replace(oc *scanner.Occurence) string {
+	if oc.Override != nil {
+		return *oc.Override
+	}
+	replacement := r[oc.Needle]
+	if oc.Captures != nil {
+		return expandCased(replacement.Value, oc)
 	}
-	return len(group.Occurences), nil
+	variants := replacement.Singular
+	if oc.Number == inflection.Plural {
+		variants = replacement.Plural
+	}
+	variant := variants.GetVariant(r.Casing(oc))
+	if oc.Casing == casing.Mimic && variant.Casing != casing.Mimic {
+		return casing.Transfer(oc.Match, variants.GetVariant(casing.Original).Value)
+	}
+	return variant.Value
+}
+
+// TotalRename will rename files and paths.
+func TotalRename(groups scanner.OccurenceGroups, replacements Replacements, rename RenameFunc, replaceFile ReplaceFileFunc) (*TotalRenameResult, error) {
+	changes, err := StageChanges(groups, replacements)
+	if err != nil {
+		return nil, err
+	}
+	return changes.Apply(rename, replaceFile)
 }
 
 // ReplaceText teplaces all occurences with their replacement variants
 // Occurences should be ordered by StartIndex.
-func ReplaceText(source string, occurences scanner.Occurences, replacementVariants casing.Variants) string {
+func ReplaceText(source string, occurences scanner.Occurences, replacements Replacements) string {
 	occurenceCount := len(occurences)
 	if occurenceCount == 0 {
 		return source
 	}
 
-	// String slices, cut at each index and leaving out the match.
-	slices := make([]string, 0, len(occurences)+2)
-
-	buf := make([]rune, 0, len(source))
-	allRunes := []rune(source)
+	// Copy the source between occurences byte by byte rather than rune by
+	// rune, so bytes that are not valid UTF-8 are left untouched.
+	result := make([]string, 0, occurenceCount*2+1)
+	last := 0
 	ocIdx := 0
-	for idx := 0; idx < len(allRunes); idx++ {
-		charCode := allRunes[idx]
-		var oc *scanner.Occurence
-		if ocIdx != occurenceCount {
-			oc = occurences[ocIdx]
+	runeIdx := 0
+	for byteIdx := range source {
+		if ocIdx == occurenceCount {
+			break
 		}
-
-		if oc != nil && idx == oc.StartIndex {
+		oc := occurences[ocIdx]
+		if runeIdx == oc.StartIndex && byteIdx >= last {
+			result = append(result, source[last:byteIdx], replacements.Replace(oc))
+			last = byteIdx + len(oc.Match)
 			ocIdx = ocIdx + 1
-			slices = append(slices, string(buf))
-			buf = make([]rune, 0, len(source))
-			idx = idx + utf8.RuneCountInString(oc.Match) - 1
-		} else {
-			buf = append(buf, charCode)
 		}
+		runeIdx = runeIdx + 1
+	}
+	result = append(result, source[last:])
+
+	return strings.Join(result, "")
+}
 
+// Expand replaces $name and ${name} in the template with the captured
+// group of that name or number, like regexp.Regexp.Expand does.
+// Use $$ for a literal $.
+func Expand(template string, captures map[string]string) string {
+	return expand(template, captures, func(literal string, _ bool) string {
+		return literal
+	})
+}
+
+// expandCased expands the template for the occurence, writing the text of
+// the template in the casing of the occurence and the captures as they are.
+func expandCased(template string, oc *scanner.Occurence) string {
+	return expand(template, oc.Captures, func(literal string, following bool) string {
+		return caseLiteral(literal, oc, following)
+	})
+}
+
+// expand expands the template, passing the text around the captures through
+// literal along with whether anything was written before it.
+func expand(template string, captures map[string]string, literal func(s string, following bool) string) string {
+	var buf strings.Builder
+	text := ""
+	flush := func() {
+		if text != "" {
+			buf.WriteString(literal(text, buf.Len() > 0))
+			text = ""
+		}
 	}
-	slices = append(slices, string(buf))
-	result := make([]string, 1, len(slices)+occurenceCount)
-	result[0] = slices[0]
-	for idx, oc := range occurences {
-		v := replacementVariants.GetVariant(oc.Casing)
-		result = append(result, v.Value, slices[idx+1])
+	for {
+		idx := strings.Index(template, "$")
+		if idx == -1 {
+			text = text + template
+			flush()
+			return buf.String()
+		}
+		text = text + template[:idx]
+		template = template[idx+1:]
+		if strings.HasPrefix(template, "$") {
+			text = text + "$"
+			template = template[1:]
+			continue
+		}
+		name, rest, ok := extractCaptureName(template)
+		if !ok {
+			text = text + "$"
+			continue
+		}
+		flush()
+		buf.WriteString(captures[name])
+		template = rest
 	}
+}
 
-	return strings.Join(result, "")
+// caseLiteral writes the text of a template in the casing of the occurence,
+// leaving separators at either end as they are. Text following a capture
+// continues the name, so it is not written like its start, as camel and
+// sentence case would.
+func caseLiteral(s string, oc *scanner.Occurence, following bool) string {
+	start := strings.IndexFunc(s, casing.IsWordRune)
+	if start == -1 {
+		return s
+	}
+	end := strings.LastIndexFunc(s, casing.IsWordRune)
+	_, size := utf8.DecodeRuneInString(s[end:])
+	end = end + size
+	c := oc.Casing
+	if following {
+		switch c {
+		case casing.CamelCase:
+			c = casing.TitleCase
+		case casing.AcronymCamelCase:
+			c = casing.AcronymTitleCase
+		case casing.SentenceCase:
+			c = casing.SpaceCase
+		}
+	}
+	cased := casing.GenerateCasings(s[start:end]).GetVariant(c).Value
+	if c == casing.Mimic {
+		cased = casing.Transfer(oc.Match, s[start:end])
+	}
+	return s[:start] + cased + s[end:]
 }
 
-// ReplaceFileContent replaces the file contents.
-func ReplaceFileContent(filePath, newContent string) error {
-	fileInfo, err := os.Stat(filePath)
-	if err != nil {
-		return err
+// extractCaptureName extracts the name from the start of s,
+// which is either {name} or a run of letters, digits and underscores.
+func extractCaptureName(s string) (name string, rest string, ok bool) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.Index(s, "}")
+		if end == -1 || !isCaptureName(s[1:end]) {
+			return "", s, false
+		}
+		return s[1:end], s[end+1:], true
 	}
-	err = ioutil.WriteFile(filePath, []byte(newContent), fileInfo.Mode())
-	return nil
+	end := 0
+	for end < len(s) && isCaptureNameChar(s[end]) {
+		end = end + 1
+	}
+	if end == 0 {
+		return "", s, false
+	}
+	return s[:end], s[end:], true
+}
+
+func isCaptureName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isCaptureNameChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isCaptureNameChar(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
 }
@@ -13,8 +13,12 @@ import (
 	"strings"
 
 	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/inflection"
+	"github.com/jeffijoe/total-rename/journal"
 	"github.com/jeffijoe/total-rename/lister"
+	"github.com/jeffijoe/total-rename/mapping"
 	"github.com/jeffijoe/total-rename/scanner"
+	"github.com/jeffijoe/total-rename/simplematch"
 	"github.com/jeffijoe/total-rename/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,9 +26,9 @@ import (
 
 func TestReplaceText(t *testing.T) {
 	type args struct {
-		source              string
-		occurences          scanner.Occurences
-		replacementVariants casing.Variants
+		source       string
+		occurences   scanner.Occurences
+		replacements Replacements
 	}
 	tests := []struct {
 		name string
@@ -38,32 +42,37 @@ func TestReplaceText(t *testing.T) {
 				source: "space is great, Spaces Are Great, SPACEMEMBERS SUCK! space_snakes are the worst, but SPACE_UPPER_SNAKES SUCK EVEN MORE!",
 				occurences: scanner.Occurences{
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.Original,
 						Match:      "space",
 						StartIndex: 0,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.TitleCase,
 						Match:      "Space",
 						StartIndex: 16,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.UpperCase,
 						Match:      "SPACE",
 						StartIndex: 34,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.Original,
 						Match:      "space",
 						StartIndex: 53,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.UpperCase,
 						Match:      "space",
 						StartIndex: 85,
 					},
 				},
-				replacementVariants: casing.GenerateCasings("board"),
+				replacements: pairs("space", "board"),
 			},
 		},
 		{
@@ -73,32 +82,37 @@ func TestReplaceText(t *testing.T) {
 				source: "space is great, Spaces Are Great, SPACEMEMBERS SUCK! space_snakes are the worst, but SPACE",
 				occurences: scanner.Occurences{
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.Original,
 						Match:      "space",
 						StartIndex: 0,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.TitleCase,
 						Match:      "Space",
 						StartIndex: 16,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.UpperCase,
 						Match:      "SPACE",
 						StartIndex: 34,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.Original,
 						Match:      "space",
 						StartIndex: 53,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.UpperCase,
 						Match:      "space",
 						StartIndex: 85,
 					},
 				},
-				replacementVariants: casing.GenerateCasings("board"),
+				replacements: pairs("space", "board"),
 			},
 		},
 		{
@@ -108,32 +122,37 @@ func TestReplaceText(t *testing.T) {
 				source: "the space is great, Spaces Are Great, SPACEMEMBERS SUCK! space_snakes are the worst, but SPACE",
 				occurences: scanner.Occurences{
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.Original,
 						Match:      "space",
 						StartIndex: 4,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.TitleCase,
 						Match:      "Space",
 						StartIndex: 20,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.UpperCase,
 						Match:      "SPACE",
 						StartIndex: 38,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.Original,
 						Match:      "space",
 						StartIndex: 57,
 					},
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.UpperCase,
 						Match:      "space",
 						StartIndex: 89,
 					},
 				},
-				replacementVariants: casing.GenerateCasings("board"),
+				replacements: pairs("space", "board"),
 			},
 		},
 		{
@@ -143,37 +162,43 @@ func TestReplaceText(t *testing.T) {
 				source: "spaceTime is space_time with SPACE_TIME for SpaceTime and SPACETIME with spacetime",
 				occurences: scanner.Occurences{
 					&scanner.Occurence{
+						Needle:     "spaceTime",
 						Casing:     casing.Original,
 						Match:      "spaceTime",
 						StartIndex: 0,
 					},
 					&scanner.Occurence{
+						Needle:     "spaceTime",
 						Casing:     casing.SnakeCase,
 						Match:      "space_time",
 						StartIndex: 13,
 					},
 					&scanner.Occurence{
+						Needle:     "spaceTime",
 						Casing:     casing.UpperSnakeCase,
 						Match:      "SPACE_TIME",
 						StartIndex: 29,
 					},
 					&scanner.Occurence{
+						Needle:     "spaceTime",
 						Casing:     casing.TitleCase,
 						Match:      "SpaceTime",
 						StartIndex: 44,
 					},
 					&scanner.Occurence{
+						Needle:     "spaceTime",
 						Casing:     casing.UpperCase,
 						Match:      "SPACETIME",
 						StartIndex: 58,
 					},
 					&scanner.Occurence{
+						Needle:     "spaceTime",
 						Casing:     casing.LowerCase,
 						Match:      "spacetime",
 						StartIndex: 73,
 					},
 				},
-				replacementVariants: casing.GenerateCasings("timeSpace"),
+				replacements: pairs("spaceTime", "timeSpace"),
 			},
 		},
 		{
@@ -183,24 +208,253 @@ func TestReplaceText(t *testing.T) {
 				source: "        helper.createSpace()",
 				occurences: scanner.Occurences{
 					&scanner.Occurence{
+						Needle:     "space",
 						Casing:     casing.TitleCase,
 						Match:      "Space",
 						StartIndex: 21,
 					},
 				},
-				replacementVariants: casing.GenerateCasings("board"),
+				replacements: pairs("space", "board"),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := ReplaceText(tt.args.source, tt.args.occurences, tt.args.replacementVariants); got != tt.want {
+			if got := ReplaceText(tt.args.source, tt.args.occurences, tt.args.replacements); got != tt.want {
 				t.Errorf("ReplaceText() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestReplaceText_Inflected(t *testing.T) {
+	replacements := NewInflectedReplacements(inflection.English(), mapping.Pairs{
+		mapping.Pair{Needle: "category", Replacement: "person"},
+	})
+	source := "categories, Category, CATEGORIES"
+	occurences := scanner.Occurences{
+		&scanner.Occurence{Needle: "category", Number: inflection.Plural, Casing: casing.LowerCase, Match: "categories", StartIndex: 0},
+		&scanner.Occurence{Needle: "category", Number: inflection.Singular, Casing: casing.TitleCase, Match: "Category", StartIndex: 12},
+		&scanner.Occurence{Needle: "category", Number: inflection.Plural, Casing: casing.UpperCase, Match: "CATEGORIES", StartIndex: 22},
+	}
+	assert.Equal(t, "people, Person, PEOPLE", ReplaceText(source, occurences, replacements))
+}
+
+func TestReplaceText_Acronyms(t *testing.T) {
+	source := "apiKey, ApiKey, APIKey, api_key, API_KEY, userID"
+	dir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "keys.txt")
+	require.NoError(t, ioutil.WriteFile(filePath, []byte(source), 0644))
+
+	occurences, err := scanner.ScanFile(filePath, scanner.NewNeedles("apiKey", "userId"))
+	require.NoError(t, err)
+	replacements := NewReplacements(mapping.Pairs{
+		mapping.Pair{Needle: "apiKey", Replacement: "authToken"},
+		mapping.Pair{Needle: "userId", Replacement: "accountId"},
+	})
+	assert.Equal(t, "authToken, AuthToken, AuthToken, auth_token, AUTH_TOKEN, accountID", ReplaceText(source, occurences, replacements))
+}
+
+func TestReplaceText_Mimic(t *testing.T) {
+	replacements := NewInflectedReplacements(inflection.English(), mapping.Pairs{
+		mapping.Pair{Needle: "space", Replacement: "boardItem"},
+	})
+	source := "sPaCe, SPACes"
+	occurences := scanner.Occurences{
+		&scanner.Occurence{Needle: "space", Casing: casing.Mimic, Match: "sPaCe", StartIndex: 0},
+		&scanner.Occurence{Needle: "space", Number: inflection.Plural, Casing: casing.Mimic, Match: "SPACes", StartIndex: 7},
+	}
+	assert.Equal(t, "boardItem, BOARDItems", ReplaceText(source, occurences, replacements))
+}
+
+func TestTotalRename_OverrideCasings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "space.go")
+	require.NoError(t, ioutil.WriteFile(filePath, []byte("const SPACE_KIND = 1\nvar space Space\n"), 0644))
+
+	needles := scanner.NewNeedles("space")
+	occurences, err := scanner.ScanFile(filePath, needles)
+	require.NoError(t, err)
+	groups := scanner.OccurenceGroups{
+		&scanner.OccurenceGroup{Path: filePath, Type: scanner.OccurenceGroupTypeContent, Occurences: occurences},
+		&scanner.OccurenceGroup{Path: filePath, Type: scanner.OccurenceGroupTypePath, Occurences: scanner.ScanFilePath(filePath, needles)},
+	}
+	replacements := pairs("space", "board")
+	require.NoError(t, replacements.OverrideCasings(CasingOverrides{"": {casing.UpperCase: "BRD", casing.TitleCase: "WorkBoard"}}, nil))
+	assert.EqualValues(t, casing.UpperCase, replacements.Casing(occurences[0]), "SPACE is upper case too, which is overridden")
+
+	_, err = TotalRename(groups, replacements, os.Rename, ReplaceFileContent)
+	require.NoError(t, err)
+	content, err := ioutil.ReadFile(filepath.Join(dir, "board.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "const BRD_KIND = 1\nvar board WorkBoard\n", string(content))
+}
+
+func TestParseCasingOverrides(t *testing.T) {
+	overrides, err := ParseCasingOverrides("upper=BRD|room:title=Hall| room:upper=HALL|")
+	require.NoError(t, err)
+	assert.Equal(t, CasingOverrides{
+		"":     {casing.UpperCase: "BRD"},
+		"room": {casing.TitleCase: "Hall", casing.UpperCase: "HALL"},
+	}, overrides)
+
+	_, err = ParseCasingOverrides("room:shouty=HALL")
+	assert.Error(t, err)
+}
+
+func TestReplacements_OverrideCasings(t *testing.T) {
+	replacements := pairs("space", "board", "room", "hall")
+	err := replacements.OverrideCasings(CasingOverrides{"": {casing.UpperCase: "BRD"}}, nil)
+	assert.EqualError(t, err, "with several needles, say which one a casing is replaced for, like room:upper=BRD")
+	err = replacements.OverrideCasings(CasingOverrides{"lobby": {casing.UpperCase: "BRD"}}, nil)
+	assert.EqualError(t, err, `"lobby" is not one of the needles`)
+
+	require.NoError(t, replacements.OverrideCasings(CasingOverrides{"space": {casing.UpperCase: "BRD"}}, nil))
+	source := "SPACE ROOM"
+	occurences := scanner.Occurences{
+		&scanner.Occurence{Needle: "space", Casing: casing.UpperCase, Casings: casing.NewSet(casing.UpperCase), Match: "SPACE", StartIndex: 0},
+		&scanner.Occurence{Needle: "room", Casing: casing.UpperCase, Casings: casing.NewSet(casing.UpperCase), Match: "ROOM", StartIndex: 6},
+	}
+	assert.Equal(t, "BRD HALL", ReplaceText(source, occurences, replacements), "only the space needle is overridden")
+}
+
+func TestReplacements_OverrideCasings_Plural(t *testing.T) {
+	rules := inflection.English()
+	replacements := NewInflectedReplacements(rules, mapping.Pairs{mapping.Pair{Needle: "space", Replacement: "board"}})
+	require.NoError(t, replacements.OverrideCasings(CasingOverrides{"": {casing.UpperCase: "BRD", casing.TitleCase: "WorkBox"}}, rules))
+	source := "SPACES Spaces SPACE"
+	occurences := scanner.Occurences{
+		&scanner.Occurence{Needle: "space", Number: inflection.Plural, Casing: casing.UpperCase, Casings: casing.NewSet(casing.UpperCase), Match: "SPACES", StartIndex: 0},
+		&scanner.Occurence{Needle: "space", Number: inflection.Plural, Casing: casing.TitleCase, Casings: casing.NewSet(casing.TitleCase), Match: "Spaces", StartIndex: 7},
+		&scanner.Occurence{Needle: "space", Casing: casing.UpperCase, Casings: casing.NewSet(casing.UpperCase), Match: "SPACE", StartIndex: 14},
+	}
+	assert.Equal(t, "BRDS WorkBoxes BRD", ReplaceText(source, occurences, replacements))
+}
+
+func TestReplaceText_Regexp(t *testing.T) {
+	replacements := NewReplacements(mapping.Pairs{
+		mapping.Pair{Needle: `v(\d+)space`, Replacement: "v${1}Board"},
+	})
+	source := "v2Space, V10SPACE, v3space"
+	occurences := scanner.Occurences{
+		&scanner.Occurence{Needle: `v(\d+)space`, Casing: casing.CamelCase, Match: "v2Space", StartIndex: 0, Captures: map[string]string{"1": "2"}},
+		&scanner.Occurence{Needle: `v(\d+)space`, Casing: casing.UpperCase, Match: "V10SPACE", StartIndex: 9, Captures: map[string]string{"1": "10"}},
+		&scanner.Occurence{Needle: `v(\d+)space`, Casing: casing.LowerCase, Match: "v3space", StartIndex: 19, Captures: map[string]string{"1": "3"}},
+	}
+	assert.Equal(t, "v2Board, V10BOARD, v3board", ReplaceText(source, occurences, replacements))
+}
+
+func TestReplaceText_RegexpCaptures(t *testing.T) {
+	needles, err := scanner.NewRegexpNeedles(`space(\w+)`)
+	require.NoError(t, err)
+	tests := []struct {
+		template string
+		source   string
+		want     string
+	}{
+		{"board$1", "SpaceABC", "BoardABC"},
+		{"board$1", "SPACEfoo", "BOARDfoo"},
+		{"board$1", "spaceBar", "boardBar"},
+		{"work board_${1}_item", "SpaceABC", "WorkBoard_ABC_Item"},
+		{"work board_${1}_item", "SPACEfoo", "WORK BOARD_foo_ITEM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template+"/"+tt.source, func(t *testing.T) {
+			replacements := NewReplacements(mapping.Pairs{
+				mapping.Pair{Needle: `space(\w+)`, Replacement: tt.template},
+			})
+			source := "/test/" + tt.source
+			occurences := scanner.ScanFilePath(source, needles)
+			assert.Equal(t, "/test/"+tt.want, ReplaceText(source, occurences, replacements))
+		})
+	}
+}
+
+func TestReplaceText_SingleWordSeveralWords(t *testing.T) {
+	tests := []struct {
+		needle string
+		match  string
+		want   string
+	}{
+		{"space", "space", "boardItem"},
+		{"space", "Space", "BoardItem"},
+		{"space", "SPACE", "BOARD_ITEM"},
+		{"Space", "space", "boardItem"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.needle+"/"+tt.match, func(t *testing.T) {
+			source := "/test/" + tt.match
+			occurences := scanner.ScanFilePath(source, scanner.NewNeedles(tt.needle))
+			assert.Equal(t, "/test/"+tt.want, ReplaceText(source, occurences, pairs(tt.needle, "boardItem")))
+		})
+	}
+}
+
+func TestReplaceText_InvalidUTF8(t *testing.T) {
+	source := "\x89space caf\xe9 space"
+	occurences := scanner.Occurences{
+		&scanner.Occurence{Needle: "space", Casing: casing.Original, Match: "space", StartIndex: 1},
+		&scanner.Occurence{Needle: "space", Casing: casing.Original, Match: "space", StartIndex: 12},
+	}
+	assert.Equal(t, "\x89board caf\xe9 board", ReplaceText(source, occurences, pairs("space", "board")))
+}
+
+func TestReplaceText_Override(t *testing.T) {
+	override := "tile"
+	source := "spaceName, spaceName"
+	occurences := scanner.Occurences{
+		&scanner.Occurence{Needle: "space", Casing: casing.Original, Match: "space", StartIndex: 0, Override: &override},
+		&scanner.Occurence{Needle: "space", Casing: casing.Original, Match: "space", StartIndex: 11},
+	}
+	assert.Equal(t, "tileName, boardName", ReplaceText(source, occurences, pairs("space", "board")))
+}
+
+func TestTotalRename_OverridePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "space.go")
+	require.NoError(t, ioutil.WriteFile(filePath, []byte{}, 0644))
+
+	occurences := scanner.ScanFilePath(filePath, scanner.NewNeedles("space"))
+	require.Equal(t, 1, len(occurences))
+	override := "tile"
+	occurences[0].Override = &override
+	groups := scanner.OccurenceGroups{
+		&scanner.OccurenceGroup{Path: filePath, Type: scanner.OccurenceGroupTypePath, Occurences: occurences},
+	}
+	_, err = TotalRename(groups, pairs("space", "board"), os.Rename, ReplaceFileContent)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "tile.go"))
+}
+
+func TestExpand(t *testing.T) {
+	captures := map[string]string{"0": "spaceId", "1": "Id", "suffix": "Id"}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"board$1", "boardId"},
+		{"board${1}s", "boardIds"},
+		{"board${suffix}", "boardId"},
+		{"board$suffix", "boardId"},
+		{"$0!", "spaceId!"},
+		{"board$$1", "board$1"},
+		{"board$", "board$"},
+		{"board${", "board${"},
+		{"board$missing", "board"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			assert.Equal(t, tt.want, Expand(tt.template, captures))
+		})
+	}
+}
+
 func TestReplaceFileContent(t *testing.T) {
 	now := time.Now().UTC().Unix()
 	file := filepath.Join(os.TempDir(), "total-rename-test-"+strconv.FormatInt(now, 10)+".txt")
@@ -208,11 +462,60 @@ func TestReplaceFileContent(t *testing.T) {
 	content, _ := ioutil.ReadFile(file)
 	assert.Equal(t, "plz", string(content))
 
-	ReplaceFileContent(file, "haha")
+	err := ReplaceFileContent(file, "haha")
+	assert.NoError(t, err)
 	content, _ = ioutil.ReadFile(file)
 	assert.Equal(t, "haha", string(content))
 }
 
+func TestReplaceFileContent_Atomic(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	file := filepath.Join(tempDir, "space.sh")
+	link := filepath.Join(tempDir, "space-link.sh")
+	require.NoError(t, ioutil.WriteFile(file, []byte("echo space"), 0750))
+	require.NoError(t, os.Symlink(file, link))
+
+	require.NoError(t, ReplaceFileContent(link, "echo board"))
+	content, _ := ioutil.ReadFile(file)
+	assert.Equal(t, "echo board", string(content))
+	fi, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), fi.Mode().Perm())
+	fi, err = os.Lstat(link)
+	require.NoError(t, err)
+	assert.True(t, fi.Mode()&os.ModeSymlink != 0, "symlink should be kept")
+	entries, _ := ioutil.ReadDir(tempDir)
+	assert.Equal(t, 2, len(entries), "no temporary files should be left behind")
+}
+
+func TestReplaceFileContent_Error(t *testing.T) {
+	err := ReplaceFileContent(filepath.Join(os.TempDir(), "total-rename-does-not-exist", "space.txt"), "board")
+	assert.Error(t, err)
+}
+
+func TestTotalRename_ReplaceFileError(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	file := filepath.Join(tempDir, "index.js")
+	require.NoError(t, ioutil.WriteFile(file, []byte("space"), 0644))
+	groups := scanner.OccurenceGroups{
+		&scanner.OccurenceGroup{
+			Path:       file,
+			Type:       scanner.OccurenceGroupTypeContent,
+			Occurences: scanner.Occurences{&scanner.Occurence{Needle: "space", Match: "space"}},
+		},
+	}
+
+	diskFull := fmt.Errorf("no space left on device")
+	_, err = TotalRename(groups, pairs("space", "board"), os.Rename, func(filePath, newContent string) error {
+		return diskFull
+	})
+	assert.Equal(t, diskFull, err)
+}
+
 func TestTotalRename(t *testing.T) {
 	fixtures, err := ioutil.ReadDir(filepath.Join(util.GetWD(), "../_fixtures"))
 	require.NoError(t, err)
@@ -234,17 +537,19 @@ func testTotalRenameFixture(fixturePath string, t *testing.T) {
 		tempDir,
 		"**/*.*",
 		".dotfolder",
+		false,
 	)
 
-	groups, _ := scanner.ScanFileNodes(nodes, "space", ".png")
+	groups, _, _ := scanner.ScanFileNodes(nodes, scanner.NewNeedles("space"), simplematch.NewMatcher(tempDir, "*.png"), simplematch.NewMatcher(tempDir, ""))
 
-	_, err := TotalRename(groups, "board", os.Rename, ReplaceFileContent)
+	_, err := TotalRename(groups, pairs("space", "board"), os.Rename, ReplaceFileContent)
 	assert.NoError(t, err)
 	expectedDir, _ := filepath.Abs(filepath.Join(fixturePath, "expected"))
 	expectedNodes, _ := lister.ListFileNodes(
 		expectedDir,
 		"**/*.*",
 		"",
+		false,
 	)
 
 	for _, node := range expectedNodes {
@@ -272,3 +577,183 @@ func testTotalRenameFixture(fixturePath string, t *testing.T) {
 	}
 	os.RemoveAll(tempDir)
 }
+
+func pairs(needleAndReplacements ...string) Replacements {
+	result := mapping.Pairs{}
+	for i := 0; i+1 < len(needleAndReplacements); i = i + 2 {
+		result = append(result, mapping.Pair{
+			Needle:      needleAndReplacements[i],
+			Replacement: needleAndReplacements[i+1],
+		})
+	}
+	return NewReplacements(result)
+}
+
+func TestTotalRenameAllOrNothing(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "space"), 0755))
+	file := filepath.Join(tempDir, "space", "space.js")
+	require.NoError(t, ioutil.WriteFile(file, []byte("const space = 1"), 0644))
+	nodes, err := lister.ListFileNodes(tempDir, "**/*", "", false)
+	require.NoError(t, err)
+	groups, _, err := scanner.ScanFileNodes(nodes, scanner.NewNeedles("space"), simplematch.NewMatcher(tempDir, ""), simplematch.NewMatcher(tempDir, ""))
+	require.NoError(t, err)
+
+	permissionDenied := fmt.Errorf("permission denied")
+	rename := func(oldPath, newPath string) error {
+		if filepath.Base(oldPath) == "space" {
+			return permissionDenied
+		}
+		return os.Rename(oldPath, newPath)
+	}
+	_, err = TotalRenameAllOrNothing(groups, pairs("space", "board"), rename, ReplaceFileContent)
+	require.IsType(t, &RollbackError{}, err)
+	rollbackErr := err.(*RollbackError)
+	assert.Equal(t, permissionDenied, rollbackErr.Err)
+	assert.Equal(t, filepath.Join(tempDir, "space"), rollbackErr.Failed.Path)
+	assert.Equal(t, 2, len(rollbackErr.Restored))
+	assert.Equal(t, 0, len(rollbackErr.NotRestored))
+	assert.EqualValues(t, ChangeTypeRename, rollbackErr.Restored[0].Type)
+	assert.EqualValues(t, ChangeTypeReplace, rollbackErr.Restored[1].Type)
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "const space = 1", string(content))
+
+	result, err := TotalRenameAllOrNothing(groups, pairs("space", "board"), os.Rename, ReplaceFileContent)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.OccurencesRenamed)
+	content, err = ioutil.ReadFile(filepath.Join(tempDir, "board", "board.js"))
+	require.NoError(t, err)
+	assert.Equal(t, "const board = 1", string(content))
+}
+
+func TestChanges_ApplyOrRollBack_Journal(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	a := filepath.Join(tempDir, "a.js")
+	b := filepath.Join(tempDir, "b.js")
+	require.NoError(t, ioutil.WriteFile(a, []byte("space a"), 0644))
+	require.NoError(t, ioutil.WriteFile(b, []byte("space b"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "space.js"), []byte{}, 0644))
+	changes := Changes{
+		&Change{Type: ChangeTypeReplace, Path: a, Original: "space a", NewContent: "board a"},
+		&Change{Type: ChangeTypeReplace, Path: b, Original: "space b", NewContent: "board b"},
+		&Change{Type: ChangeTypeRename, Path: filepath.Join(tempDir, "space.js"), NewPath: filepath.Join(tempDir, "board.js")},
+	}
+
+	j := journal.New([]string{"space", "board"})
+	rename := func(oldPath, newPath string) error {
+		return fmt.Errorf("permission denied")
+	}
+	restoreFile := func(filePath, newContent string) error {
+		if filePath == b {
+			return fmt.Errorf("permission denied")
+		}
+		return ReplaceFileContent(filePath, newContent)
+	}
+	_, err = changes.ApplyOrRollBack(j.Rename(rename), j.ReplaceFile(ReplaceFileContent), j.RenameBack(os.Rename), j.ReplaceFileBack(restoreFile))
+	require.IsType(t, &RollbackError{}, err)
+	rollbackErr := err.(*RollbackError)
+	assert.Equal(t, 1, len(rollbackErr.Restored))
+	assert.Equal(t, 1, len(rollbackErr.NotRestored))
+	require.Equal(t, 1, len(j.Entries), "only the change that was not rolled back is left to undo")
+	assert.Equal(t, b, j.Entries[0].Path)
+
+	require.NoError(t, j.Undo(os.Rename, ReplaceFileContent))
+	content, err := ioutil.ReadFile(a)
+	require.NoError(t, err)
+	assert.Equal(t, "space a", string(content))
+	content, err = ioutil.ReadFile(b)
+	require.NoError(t, err)
+	assert.Equal(t, "space b", string(content))
+}
+
+func TestResolveCollisions(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "space.js"), []byte{}, 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "board.js"), []byte{}, 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "room.js"), []byte{}, 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "lobby.js"), []byte{}, 0644))
+	stage := func() Changes {
+		return Changes{
+			&Change{Type: ChangeTypeRename, Path: filepath.Join(tempDir, "space.js"), NewPath: filepath.Join(tempDir, "board.js")},
+			&Change{Type: ChangeTypeRename, Path: filepath.Join(tempDir, "room.js"), NewPath: filepath.Join(tempDir, "hall.js")},
+			&Change{Type: ChangeTypeRename, Path: filepath.Join(tempDir, "lobby.js"), NewPath: filepath.Join(tempDir, "hall.js")},
+		}
+	}
+
+	_, collisions, err := ResolveCollisions(stage(), CollisionAbort)
+	require.IsType(t, &CollisionError{}, err)
+	assert.Equal(t, 2, len(collisions))
+	assert.Equal(t, filepath.Join(tempDir, "board.js"), collisions[0].Target)
+	assert.Equal(t, "", collisions[0].With)
+	assert.Equal(t, filepath.Join(tempDir, "room.js"), collisions[1].With)
+
+	changes, collisions, err := ResolveCollisions(stage(), CollisionSkip)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(collisions))
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, filepath.Join(tempDir, "room.js"), changes[0].Path)
+
+	changes, _, err = ResolveCollisions(stage(), CollisionSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(changes))
+	assert.Equal(t, filepath.Join(tempDir, "board-2.js"), changes[0].NewPath)
+	assert.Equal(t, filepath.Join(tempDir, "hall-2.js"), changes[2].NewPath)
+
+	_, collisions, err = ResolveCollisions(stage(), CollisionMerge)
+	require.IsType(t, &CollisionError{}, err)
+	assert.Error(t, collisions[0].Err, "files can not be merged")
+}
+
+func TestResolveCollisions_Merge(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	for _, p := range []string{"spaces/a.js", "spaces/shared/b.js", "boards/c.js", "boards/shared/d.js"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(p)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, p), []byte(p), 0644))
+	}
+	changes, collisions, err := ResolveCollisions(Changes{
+		&Change{Type: ChangeTypeRename, Path: filepath.Join(tempDir, "spaces"), NewPath: filepath.Join(tempDir, "boards"), Occurences: 1},
+	}, CollisionMerge)
+	require.NoError(t, err)
+	assert.EqualValues(t, CollisionMerge, collisions[0].Resolution)
+	assert.True(t, changes[0].Merge)
+
+	_, err = changes.Apply(os.Rename, ReplaceFileContent)
+	require.NoError(t, err)
+	for _, p := range []string{"boards/a.js", "boards/c.js", "boards/shared/b.js", "boards/shared/d.js"} {
+		_, err := os.Stat(filepath.Join(tempDir, p))
+		assert.NoError(t, err, p)
+	}
+	_, err = os.Stat(filepath.Join(tempDir, "spaces"))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, changes[0].revert(os.Rename, ReplaceFileContent))
+	for _, p := range []string{"spaces/a.js", "spaces/shared/b.js", "boards/c.js", "boards/shared/d.js"} {
+		content, err := ioutil.ReadFile(filepath.Join(tempDir, p))
+		assert.NoError(t, err, p)
+		assert.Equal(t, p, string(content))
+	}
+}
+
+func TestResolveCollisions_MergeConflict(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	for _, p := range []string{"spaces/index.js", "boards/index.js"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(p)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, p), []byte(p), 0644))
+	}
+	_, collisions, err := ResolveCollisions(Changes{
+		&Change{Type: ChangeTypeRename, Path: filepath.Join(tempDir, "spaces"), NewPath: filepath.Join(tempDir, "boards")},
+	}, CollisionMerge)
+	require.IsType(t, &CollisionError{}, err)
+	assert.Contains(t, collisions[0].Err.Error(), "index.js exists in both")
+}
@@ -0,0 +1,199 @@
+package replacer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jeffijoe/total-rename/scanner"
+)
+
+// ChangeType determines what kind of change it is.
+type ChangeType uint8
+
+// Types of changes.
+const (
+	ChangeTypeReplace = ChangeType(iota)
+	ChangeTypeRename
+)
+
+// Changes is a list of changes, in the order they are applied.
+type Changes []*Change
+
+// Change is a staged content replacement or path move. Replacements
+// keep the original contents of the file so they can be rolled back.
+// When Merge is set, the folder at Path is merged into the existing
+// folder at NewPath.
+type Change struct {
+	Type       ChangeType
+	Path       string
+	NewPath    string
+	Merge      bool
+	Original   string
+	NewContent string
+	Occurences int
+	moved      []move
+}
+
+// RestoreFailure is a change that could not be rolled back.
+type RestoreFailure struct {
+	Change *Change
+	Err    error
+}
+
+// RollbackError is returned by TotalRenameAllOrNothing when applying a
+// change failed. Every change applied before it has been rolled back,
+// except for the ones in NotRestored.
+type RollbackError struct {
+	Failed      *Change
+	Err         error
+	Restored    Changes
+	NotRestored []*RestoreFailure
+}
+
+func (e *RollbackError) Error() string {
+	if len(e.NotRestored) > 0 {
+		return fmt.Sprintf("could not %s: %v; rolled back %d changes, %d could not be rolled back", e.Failed, e.Err, len(e.Restored), len(e.NotRestored))
+	}
+	return fmt.Sprintf("could not %s: %v; rolled back %d changes", e.Failed, e.Err, len(e.Restored))
+}
+
+// StageChanges determines the changes to make for the occurence groups,
+// without making them.
+func StageChanges(groups scanner.OccurenceGroups, replacements Replacements) (Changes, error) {
+	result := make(Changes, 0, len(groups))
+	for _, group := range groups {
+		switch group.Type {
+		case scanner.OccurenceGroupTypeContent:
+			contentBytes, err := ioutil.ReadFile(group.Path)
+			if err != nil {
+				return nil, err
+			}
+			content := string(contentBytes)
+			result = append(result, &Change{
+				Type:       ChangeTypeReplace,
+				Path:       group.Path,
+				Original:   content,
+				NewContent: ReplaceText(content, group.Occurences, replacements),
+				Occurences: len(group.Occurences),
+			})
+		case scanner.OccurenceGroupTypePath:
+			result = append(result, &Change{
+				Type:       ChangeTypeRename,
+				Path:       group.Path,
+				NewPath:    ReplaceText(group.Path, group.Occurences, replacements),
+				Occurences: len(group.Occurences),
+			})
+		}
+	}
+	return result, nil
+}
+
+// TotalRenameAllOrNothing renames files and paths like TotalRename, but
+// rolls back the changes already applied when one of them fails,
+// returning a *RollbackError.
+func TotalRenameAllOrNothing(groups scanner.OccurenceGroups, replacements Replacements, rename RenameFunc, replaceFile ReplaceFileFunc) (*TotalRenameResult, error) {
+	changes, err := StageChanges(groups, replacements)
+	if err != nil {
+		return nil, err
+	}
+	return changes.ApplyAllOrNothing(rename, replaceFile)
+}
+
+// Apply applies the changes in order, stopping at the first one that fails.
+func (slice Changes) Apply(rename RenameFunc, replaceFile ReplaceFileFunc) (*TotalRenameResult, error) {
+	for _, change := range slice {
+		if err := change.apply(rename, replaceFile); err != nil {
+			return nil, err
+		}
+	}
+	return &TotalRenameResult{
+		OccurencesRenamed: slice.Occurences(),
+	}, nil
+}
+
+// ApplyAllOrNothing applies the changes in order. When one of them fails,
+// the changes already applied are rolled back and a *RollbackError is returned.
+func (slice Changes) ApplyAllOrNothing(rename RenameFunc, replaceFile ReplaceFileFunc) (*TotalRenameResult, error) {
+	return slice.ApplyOrRollBack(rename, replaceFile, rename, replaceFile)
+}
+
+// ApplyOrRollBack is like ApplyAllOrNothing, but rolls back through
+// restoreRename and restoreFile, so the rollback can be kept apart
+// from the changes, like in the undo journal.
+func (slice Changes) ApplyOrRollBack(rename RenameFunc, replaceFile ReplaceFileFunc, restoreRename RenameFunc, restoreFile ReplaceFileFunc) (*TotalRenameResult, error) {
+	for i, change := range slice {
+		if err := change.apply(rename, replaceFile); err != nil {
+			return nil, rollback(slice[:i], change, err, restoreRename, restoreFile)
+		}
+	}
+	return &TotalRenameResult{
+		OccurencesRenamed: slice.Occurences(),
+	}, nil
+}
+
+// Occurences returns the number of occurences the changes replace.
+func (slice Changes) Occurences() int {
+	result := 0
+	for _, change := range slice {
+		result = result + change.Occurences
+	}
+	return result
+}
+
+// rollback reverts the applied changes, newest first.
+func rollback(applied Changes, failed *Change, err error, rename RenameFunc, replaceFile ReplaceFileFunc) *RollbackError {
+	result := &RollbackError{
+		Failed:      failed,
+		Err:         err,
+		Restored:    Changes{},
+		NotRestored: []*RestoreFailure{},
+	}
+	for i := len(applied) - 1; i >= 0; i-- {
+		change := applied[i]
+		if err := change.revert(rename, replaceFile); err != nil {
+			result.NotRestored = append(result.NotRestored, &RestoreFailure{change, err})
+			continue
+		}
+		result.Restored = append(result.Restored, change)
+	}
+	return result
+}
+
+func (c *Change) apply(rename RenameFunc, replaceFile ReplaceFileFunc) error {
+	if c.Type == ChangeTypeRename && c.Merge {
+		return c.merge(rename)
+	}
+	if c.Type == ChangeTypeRename {
+		return rename(c.Path, c.NewPath)
+	}
+	return replaceFile(c.Path, c.NewContent)
+}
+
+func (c *Change) revert(rename RenameFunc, replaceFile ReplaceFileFunc) error {
+	if c.Type == ChangeTypeRename && c.Merge {
+		return c.unmerge(rename)
+	}
+	if c.Type == ChangeTypeRename {
+		return rename(c.NewPath, c.Path)
+	}
+	return replaceFile(c.Path, c.Original)
+}
+
+func (c *Change) String() string {
+	if c.Type == ChangeTypeRename && c.Merge {
+		return fmt.Sprintf("merge %s into %s", c.Path, c.NewPath)
+	}
+	if c.Type == ChangeTypeRename {
+		return fmt.Sprintf("rename %s to %s", c.Path, c.NewPath)
+	}
+	return fmt.Sprintf("replace the contents of %s", c.Path)
+}
+
+func (slice Changes) String() string {
+	result := []string{}
+	for _, c := range slice {
+		result = append(result, c.String())
+	}
+	return strings.Join(result, "\n")
+}
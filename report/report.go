@@ -0,0 +1,154 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/jeffijoe/total-rename/replacer"
+	"github.com/jeffijoe/total-rename/scanner"
+)
+
+// Version is the version of the report schema. It is increased
+// whenever a field is removed or changes its meaning.
+const Version = 1
+
+// Report is the machine readable result of a scan.
+type Report struct {
+	Version int      `json:"version"`
+	Groups  []*Group `json:"groups"`
+}
+
+// Group is a file whose contents or path have occurences, or a file
+// whose contents were skipped because they look binary.
+type Group struct {
+	// Version is only set on the lines of an NDJSON report.
+	Version    int          `json:"version,omitempty"`
+	Type       string       `json:"type"`
+	Path       string       `json:"path"`
+	Occurences []*Occurence `json:"occurences"`
+}
+
+// Occurence is an occurence of a needle. Casing is the casing it is
+// replaced in, and Casings every casing it is written in. Line and Column are 1-based,
+// Column counting characters; path occurences have no line, and their
+// column and offset point into the path. Offset is the byte index of the
+// match.
+type Occurence struct {
+	Needle      string            `json:"needle"`
+	Number      string            `json:"number"`
+	Casing      string            `json:"casing"`
+	Casings     []string          `json:"casings,omitempty"`
+	Match       string            `json:"match"`
+	Line        int               `json:"line,omitempty"`
+	Column      int               `json:"column"`
+	Offset      int               `json:"offset"`
+	Replacement string            `json:"replacement"`
+	Captures    map[string]string `json:"captures,omitempty"`
+}
+
+// Group types
+const (
+	GroupTypeContent = "content"
+	GroupTypePath    = "path"
+	GroupTypeBinary  = "binary"
+)
+
+// New creates a report of the groups, with the occurences replaced using
+// the replacements, and the binary files that were skipped.
+func New(groups scanner.OccurenceGroups, binaries []string, replacements replacer.Replacements) *Report {
+	r := &Report{
+		Version: Version,
+		Groups:  make([]*Group, 0, len(groups)+len(binaries)),
+	}
+	for _, group := range groups {
+		r.Groups = append(r.Groups, newGroup(group, replacements))
+	}
+	for _, binary := range binaries {
+		r.Groups = append(r.Groups, &Group{
+			Type:       GroupTypeBinary,
+			Path:       binary,
+			Occurences: []*Occurence{},
+		})
+	}
+	return r
+}
+
+func newGroup(group *scanner.OccurenceGroup, replacements replacer.Replacements) *Group {
+	g := &Group{
+		Type:       GroupTypePath,
+		Path:       group.Path,
+		Occurences: make([]*Occurence, 0, len(group.Occurences)),
+	}
+	if group.Type == scanner.OccurenceGroupTypeContent {
+		g.Type = GroupTypeContent
+	}
+	for _, oc := range group.Occurences {
+		o := &Occurence{
+			Needle:      oc.Needle,
+			Number:      oc.Number.String(),
+			Casing:      replacements.Casing(oc).String(),
+			Match:       oc.Match,
+			Column:      utf8.RuneCountInString(oc.Line[:oc.LineStartIndex]) + 1,
+			Offset:      oc.Offset,
+			Replacement: replacements.Replace(oc),
+			Captures:    oc.Captures,
+		}
+		if oc.Casings != 0 {
+			o.Casings = oc.Casings.Names()
+		}
+		if g.Type == GroupTypeContent {
+			o.Line = oc.LineNumber + 1
+		}
+		g.Occurences = append(g.Occurences, o)
+	}
+	return g
+}
+
+// WriteJSON writes the report as a single indented JSON document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteNDJSON writes the report as newline delimited JSON,
+// one group per line, each with the schema version.
+func (r *Report) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, g := range r.Groups {
+		line := *g
+		line.Version = r.Version
+		if err := enc.Encode(&line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary says how many occurences the groups have, counting the files
+// with occurences in their contents apart from the occurences in paths.
+func Summary(groups scanner.OccurenceGroups) string {
+	content, paths := 0, 0
+	files := map[string]bool{}
+	for _, group := range groups {
+		if group.Type == scanner.OccurenceGroupTypePath {
+			paths = paths + len(group.Occurences)
+			continue
+		}
+		if len(group.Occurences) > 0 {
+			content = content + len(group.Occurences)
+			files[group.Path] = true
+		}
+	}
+	switch {
+	case content == 0 && paths == 0:
+		return "No occurences found."
+	case paths == 0:
+		return fmt.Sprintf("Found %d occurences in %d files.", content, len(files))
+	case content == 0:
+		return fmt.Sprintf("Found %d occurences in paths.", paths)
+	}
+	return fmt.Sprintf("Found %d occurences, %d in %d files and %d in paths.", content+paths, content, len(files), paths)
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/mapping"
+	"github.com/jeffijoe/total-rename/replacer"
+	"github.com/jeffijoe/total-rename/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGroups() scanner.OccurenceGroups {
+	return scanner.OccurenceGroups{
+		&scanner.OccurenceGroup{
+			Path: "/root/spaces/space.js",
+			Type: scanner.OccurenceGroupTypeContent,
+			Occurences: scanner.Occurences{
+				&scanner.Occurence{Needle: "space", Casing: casing.TitleCase, Casings: casing.NewSet(casing.TitleCase, casing.AcronymTitleCase), Match: "Space", Line: "// ü Space", LineStartIndex: 6, Offset: 24, LineNumber: 1},
+			},
+		},
+		&scanner.OccurenceGroup{
+			Path: "/root/spaces",
+			Type: scanner.OccurenceGroupTypePath,
+			Occurences: scanner.Occurences{
+				&scanner.Occurence{Needle: "space", Casing: casing.Original, Match: "space", Line: "/root/spaces", LineStartIndex: 6, Offset: 6},
+			},
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	replacements := replacer.NewReplacements(mapping.Pairs{mapping.Pair{Needle: "space", Replacement: "board"}})
+	r := New(testGroups(), []string{"/root/logo.png"}, replacements)
+
+	assert.Equal(t, &Report{
+		Version: Version,
+		Groups: []*Group{
+			&Group{
+				Type: GroupTypeContent,
+				Path: "/root/spaces/space.js",
+				Occurences: []*Occurence{
+					&Occurence{Needle: "space", Number: "singular", Casing: "title", Casings: []string{"title", "acronym-title"}, Match: "Space", Line: 2, Column: 6, Offset: 24, Replacement: "Board"},
+				},
+			},
+			&Group{
+				Type: GroupTypePath,
+				Path: "/root/spaces",
+				Occurences: []*Occurence{
+					&Occurence{Needle: "space", Number: "singular", Casing: "original", Match: "space", Column: 7, Offset: 6, Replacement: "board"},
+				},
+			},
+			&Group{Type: GroupTypeBinary, Path: "/root/logo.png", Occurences: []*Occurence{}},
+		},
+	}, r)
+}
+
+func TestReport_WriteNDJSON(t *testing.T) {
+	replacements := replacer.NewReplacements(mapping.Pairs{mapping.Pair{Needle: "space", Replacement: "board"}})
+	r := New(testGroups(), []string{}, replacements)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, r.WriteNDJSON(buf))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, []string{
+		`{"version":1,"type":"content","path":"/root/spaces/space.js","occurences":[{"needle":"space","number":"singular","casing":"title","casings":["title","acronym-title"],"match":"Space","line":2,"column":6,"offset":24,"replacement":"Board"}]}`,
+		`{"version":1,"type":"path","path":"/root/spaces","occurences":[{"needle":"space","number":"singular","casing":"original","match":"space","column":7,"offset":6,"replacement":"board"}]}`,
+	}, lines)
+	assert.Equal(t, 0, r.Groups[0].Version, "writing must not change the report")
+}
+
+func TestSummary(t *testing.T) {
+	groups := testGroups()
+	groups = append(groups, &scanner.OccurenceGroup{
+		Path:       "/root/spaces/space.js",
+		Type:       scanner.OccurenceGroupTypePath,
+		Occurences: scanner.Occurences{&scanner.Occurence{Needle: "space", Match: "space"}},
+	})
+	tests := []struct {
+		name   string
+		groups scanner.OccurenceGroups
+		want   string
+	}{
+		{name: "none", groups: scanner.OccurenceGroups{}, want: "No occurences found."},
+		{name: "contents", groups: groups[:1], want: "Found 1 occurences in 1 files."},
+		{name: "paths", groups: groups[1:], want: "Found 2 occurences in paths."},
+		{name: "both", groups: groups, want: "Found 3 occurences, 1 in 1 files and 2 in paths."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Summary(tt.groups))
+		})
+	}
+}
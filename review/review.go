@@ -0,0 +1,193 @@
+package review
+
+import "github.com/jeffijoe/total-rename/scanner"
+
+// Decision is whether an occurence is renamed.
+type Decision uint8
+
+// Decisions
+const (
+	Undecided = Decision(iota)
+	Accepted
+	Skipped
+)
+
+// Item is an occurence up for review, along with the group it is in.
+type Item struct {
+	Group     *scanner.OccurenceGroup
+	Occurence *scanner.Occurence
+	Decision  Decision
+}
+
+// Session walks through the occurences of every group in order, one at a
+// time. Decisions can apply to more than the current occurence, like the
+// rest of its group or every occurence in its casing, and every decision
+// can be taken back.
+type Session struct {
+	Items   []*Item
+	current int
+	history []step
+}
+
+// step is the state of a session before a decision, so it can be restored.
+type step struct {
+	current   int
+	decisions []Decision
+	overrides []*string
+}
+
+// NewSession creates a session reviewing the occurences of the groups.
+func NewSession(groups scanner.OccurenceGroups) *Session {
+	s := &Session{Items: []*Item{}}
+	for _, group := range groups {
+		for _, oc := range group.Occurences {
+			s.Items = append(s.Items, &Item{Group: group, Occurence: oc})
+		}
+	}
+	return s
+}
+
+// Current returns the occurence up for review, nil when the session is done.
+func (s *Session) Current() *Item {
+	if s.Done() {
+		return nil
+	}
+	return s.Items[s.current]
+}
+
+// Position returns the index of the current occurence.
+func (s *Session) Position() int {
+	return s.current
+}
+
+// Done checks whether every occurence has been decided on.
+func (s *Session) Done() bool {
+	return s.current >= len(s.Items)
+}
+
+// Accept renames the current occurence.
+func (s *Session) Accept() {
+	s.decide(Accepted, func(*Item) bool { return false })
+}
+
+// AcceptAs renames the current occurence to replacement
+// instead of its own replacement.
+func (s *Session) AcceptAs(replacement string) {
+	item := s.Current()
+	if item == nil {
+		return
+	}
+	s.decide(Accepted, func(*Item) bool { return false })
+	item.Occurence.Override = &replacement
+}
+
+// Skip leaves the current occurence as it is.
+func (s *Session) Skip() {
+	s.decide(Skipped, func(*Item) bool { return false })
+}
+
+// AcceptGroup renames the current occurence and the rest of its group.
+func (s *Session) AcceptGroup() {
+	group := s.Current().Group
+	s.decide(Accepted, func(item *Item) bool { return item.Group == group })
+}
+
+// SkipGroup leaves the current occurence and the rest of its group as they are.
+func (s *Session) SkipGroup() {
+	group := s.Current().Group
+	s.decide(Skipped, func(item *Item) bool { return item.Group == group })
+}
+
+// AcceptCasing renames the current occurence and every
+// following occurence in the same casing.
+func (s *Session) AcceptCasing() {
+	c := s.Current().Occurence.Casing
+	s.decide(Accepted, func(item *Item) bool { return item.Occurence.Casing == c })
+}
+
+// Quit skips every occurence that has not been decided on.
+func (s *Session) Quit() {
+	s.decide(Skipped, func(*Item) bool { return true })
+}
+
+// Back takes back the last decision, returning false
+// when there is nothing to take back.
+func (s *Session) Back() bool {
+	if len(s.history) == 0 {
+		return false
+	}
+	last := s.history[len(s.history)-1]
+	s.history = s.history[:len(s.history)-1]
+	s.current = last.current
+	for i, item := range s.Items {
+		item.Decision = last.decisions[i]
+		item.Occurence.Override = last.overrides[i]
+	}
+	return true
+}
+
+// decide decides on the current occurence and the following
+// undecided ones that match, then moves on to the next undecided one.
+func (s *Session) decide(decision Decision, matches func(*Item) bool) {
+	if s.Done() {
+		return
+	}
+	before := step{
+		current:   s.current,
+		decisions: make([]Decision, len(s.Items)),
+		overrides: make([]*string, len(s.Items)),
+	}
+	for i, item := range s.Items {
+		before.decisions[i] = item.Decision
+		before.overrides[i] = item.Occurence.Override
+	}
+	s.history = append(s.history, before)
+
+	s.Items[s.current].Decision = decision
+	for _, item := range s.Items[s.current+1:] {
+		if item.Decision == Undecided && matches(item) {
+			item.Decision = decision
+		}
+	}
+	for !s.Done() && s.Items[s.current].Decision != Undecided {
+		s.current = s.current + 1
+	}
+}
+
+// Counts returns the number of accepted and skipped occurences in the group.
+func (s *Session) Counts(group *scanner.OccurenceGroup) (accepted int, skipped int) {
+	for _, item := range s.Items {
+		if item.Group != group {
+			continue
+		}
+		switch item.Decision {
+		case Accepted:
+			accepted = accepted + 1
+		case Skipped:
+			skipped = skipped + 1
+		}
+	}
+	return accepted, skipped
+}
+
+// Groups returns the groups with only the accepted occurences,
+// leaving out groups without any.
+func (s *Session) Groups() scanner.OccurenceGroups {
+	result := scanner.OccurenceGroups{}
+	var current *scanner.OccurenceGroup
+	for _, item := range s.Items {
+		if item.Decision != Accepted {
+			continue
+		}
+		if current == nil || current.Path != item.Group.Path || current.Type != item.Group.Type {
+			current = &scanner.OccurenceGroup{
+				Path:       item.Group.Path,
+				Type:       item.Group.Type,
+				Occurences: scanner.Occurences{},
+			}
+			result = append(result, current)
+		}
+		current.Occurences = append(current.Occurences, item.Occurence)
+	}
+	return result
+}
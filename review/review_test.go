@@ -0,0 +1,172 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/scanner"
+	"github.com/stretchr/testify/assert"
+)
+
+func testGroups() scanner.OccurenceGroups {
+	return scanner.OccurenceGroups{
+		&scanner.OccurenceGroup{
+			Path: "/root/space.js",
+			Type: scanner.OccurenceGroupTypeContent,
+			Occurences: scanner.Occurences{
+				&scanner.Occurence{Casing: casing.Original, Match: "space", StartIndex: 0},
+				&scanner.Occurence{Casing: casing.TitleCase, Match: "Space", StartIndex: 10},
+				&scanner.Occurence{Casing: casing.UpperCase, Match: "SPACE", StartIndex: 20},
+			},
+		},
+		&scanner.OccurenceGroup{
+			Path: "/root/other.js",
+			Type: scanner.OccurenceGroupTypeContent,
+			Occurences: scanner.Occurences{
+				&scanner.Occurence{Casing: casing.TitleCase, Match: "Space", StartIndex: 0},
+				&scanner.Occurence{Casing: casing.Original, Match: "space", StartIndex: 10},
+			},
+		},
+	}
+}
+
+// matches returns the path and match of every accepted occurence,
+// along with its override when it has one.
+func matches(groups scanner.OccurenceGroups) []string {
+	result := []string{}
+	for _, g := range groups {
+		for _, oc := range g.Occurences {
+			match := g.Path + ":" + oc.Match
+			if oc.Override != nil {
+				match = match + "=" + *oc.Override
+			}
+			result = append(result, match)
+		}
+	}
+	return result
+}
+
+func TestSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     func(s *Session)
+		expected []string
+	}{
+		{
+			name: "accept and skip",
+			keys: func(s *Session) {
+				s.Accept()
+				s.Skip()
+				s.Accept()
+				s.Skip()
+				s.Accept()
+			},
+			expected: []string{"/root/space.js:space", "/root/space.js:SPACE", "/root/other.js:space"},
+		},
+		{
+			name: "accept the rest of the group",
+			keys: func(s *Session) {
+				s.Skip()
+				s.AcceptGroup()
+				s.Skip()
+				s.Skip()
+			},
+			expected: []string{"/root/space.js:Space", "/root/space.js:SPACE"},
+		},
+		{
+			name: "skip the rest of the group",
+			keys: func(s *Session) {
+				s.Accept()
+				s.SkipGroup()
+				s.Accept()
+				s.Accept()
+			},
+			expected: []string{"/root/space.js:space", "/root/other.js:Space", "/root/other.js:space"},
+		},
+		{
+			name: "accept the casing",
+			keys: func(s *Session) {
+				s.Skip()
+				s.AcceptCasing()
+				s.Skip()
+				s.Skip()
+			},
+			expected: []string{"/root/space.js:Space", "/root/other.js:Space"},
+		},
+		{
+			name: "quit",
+			keys: func(s *Session) {
+				s.Accept()
+				s.Quit()
+			},
+			expected: []string{"/root/space.js:space"},
+		},
+		{
+			name: "back",
+			keys: func(s *Session) {
+				s.Accept()
+				s.Back()
+				s.Skip()
+				s.AcceptCasing()
+				s.Back()
+				s.Skip()
+				s.Skip()
+				s.Accept()
+				s.Skip()
+			},
+			expected: []string{"/root/other.js:Space"},
+		},
+		{
+			name: "accept as",
+			keys: func(s *Session) {
+				s.AcceptAs("tile")
+				s.Skip()
+				s.Skip()
+				s.Skip()
+				s.Accept()
+			},
+			expected: []string{"/root/space.js:space=tile", "/root/other.js:space"},
+		},
+		{
+			name: "back after accept as",
+			keys: func(s *Session) {
+				s.AcceptAs("tile")
+				s.Back()
+				s.Accept()
+				s.Quit()
+			},
+			expected: []string{"/root/space.js:space"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSession(testGroups())
+			tt.keys(s)
+			assert.True(t, s.Done())
+			assert.Equal(t, tt.expected, matches(s.Groups()))
+		})
+	}
+}
+
+func TestSession_SkipsDecidedOccurences(t *testing.T) {
+	s := NewSession(testGroups())
+	s.AcceptCasing()
+	assert.Equal(t, 1, s.Position())
+	s.Skip()
+	s.Skip()
+	assert.Equal(t, 3, s.Position(), "the original casing in other.js was already accepted")
+	assert.Equal(t, "Space", s.Current().Occurence.Match)
+
+	replaced, skipped := s.Counts(s.Items[0].Group)
+	assert.Equal(t, 1, replaced)
+	assert.Equal(t, 2, skipped)
+}
+
+func TestSession_Back(t *testing.T) {
+	s := NewSession(testGroups())
+	assert.False(t, s.Back())
+	s.Accept()
+	assert.True(t, s.Back())
+	assert.Equal(t, 0, s.Position())
+	assert.Equal(t, Undecided, s.Current().Decision)
+}
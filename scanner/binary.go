@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"unicode/utf8"
+)
+
+// ErrBinaryContent is returned by ScanFile when the contents of
+// the file look binary.
+var ErrBinaryContent = errors.New("file contents look binary")
+
+// SniffLength is how many bytes from the start of a file are
+// examined to decide whether it is binary.
+const SniffLength = 8000
+
+// maxInvalidUTF8Ratio is the share of bytes that may be invalid UTF-8
+// before a file is considered binary. Text in a legacy encoding like
+// Latin-1 has a few invalid bytes, binary data has a lot of them.
+const maxInvalidUTF8Ratio = 0.1
+
+// magicNumbers are signatures of common binary formats that can
+// otherwise pass for text.
+var magicNumbers = [][]byte{
+	[]byte("\x89PNG\r\n\x1a\n"),
+	[]byte("GIF87a"),
+	[]byte("GIF89a"),
+	[]byte("\xff\xd8\xff"),       // JPEG
+	[]byte("%PDF-"),              // PDF
+	[]byte("PK\x03\x04"),         // zip, jar, docx, ...
+	[]byte("\x1f\x8b"),           // gzip
+	[]byte("7z\xbc\xaf\x27\x1c"), // 7-Zip
+	[]byte("Rar!\x1a\x07"),       // RAR
+	[]byte("wOFF"),               // WOFF
+	[]byte("wOF2"),               // WOFF2
+	[]byte("\x7fELF"),            // ELF
+	[]byte("\xca\xfe\xba\xbe"),   // Java class, Mach-O universal binary
+	[]byte("\xcf\xfa\xed\xfe"),   // Mach-O
+	[]byte("\x28\xb5\x2f\xfd"),   // zstd
+	[]byte("OggS"),               // Ogg
+	[]byte("fLaC"),               // FLAC
+	[]byte("\x1a\x45\xdf\xa3"),   // Matroska, WebM
+	[]byte("\xd0\xcf\x11\xe0"),   // Legacy Office documents
+}
+
+// IsBinary decides whether content looks binary by examining its first
+// SniffLength bytes for known magic numbers, NUL bytes and the
+// density of invalid UTF-8.
+func IsBinary(content []byte) bool {
+	if len(content) > SniffLength {
+		content = content[:SniffLength]
+	}
+	for _, magic := range magicNumbers {
+		if bytes.HasPrefix(content, magic) {
+			return true
+		}
+	}
+	if isRIFF(content) {
+		return true
+	}
+	if bytes.IndexByte(content, 0) != -1 {
+		return true
+	}
+	invalid := 0
+	for i := 0; i < len(content); {
+		if !utf8.FullRune(content[i:]) {
+			// A rune cut in half by the end of the sniffed range.
+			break
+		}
+		r, size := utf8.DecodeRune(content[i:])
+		if r == utf8.RuneError && size == 1 {
+			invalid = invalid + 1
+		}
+		i = i + size
+	}
+	return len(content) > 0 && float64(invalid)/float64(len(content)) > maxInvalidUTF8Ratio
+}
+
+// isRIFF checks for RIFF containers, like WAV, AVI and WebP.
+func isRIFF(content []byte) bool {
+	if len(content) < 12 || !bytes.HasPrefix(content, []byte("RIFF")) {
+		return false
+	}
+	switch string(content[8:12]) {
+	case "WAVE", "AVI ", "WEBP":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"github.com/jeffijoe/total-rename/casing"
+)
+
+// wordBoundaries splits s into words the way casing.Words does and returns
+// the byte offsets where a word starts or ends, as well as the offsets
+// around anything that is not a letter or digit:
+//
+//	mySpaceList   -> my|Space|List
+//	HTTPServer    -> HTTP|Server
+//	SPACE_NAME    -> SPACE|_|NAME
+//	v2Space       -> v2|Space
+func wordBoundaries(s string) map[int]bool {
+	runes := make([]rune, 0, len(s))
+	offsets := make([]int, 0, len(s)+1)
+	for offset, r := range s {
+		runes = append(runes, r)
+		offsets = append(offsets, offset)
+	}
+	offsets = append(offsets, len(s))
+
+	result := map[int]bool{0: true, len(s): true}
+	for _, span := range casing.WordSpans(runes) {
+		result[offsets[span[0]]] = true
+		result[offsets[span[1]]] = true
+	}
+	for i, r := range runes {
+		if !casing.IsWordRune(r) {
+			result[offsets[i]] = true
+			result[offsets[i+1]] = true
+		}
+	}
+	return result
+}
+
+// isOnWordBoundaries checks whether the match starting at the byte offset
+// starts and ends on a word boundary.
+func isOnWordBoundaries(boundaries map[int]bool, offset int, match string) bool {
+	return boundaries[offset] && boundaries[offset+len(match)]
+}
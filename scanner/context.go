@@ -0,0 +1,39 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ContextReader reads the lines around content occurences from their
+// files when they are needed, rather than keeping them on every occurence.
+// The lines of the last file read are kept, as occurences are usually
+// looked at file by file.
+type ContextReader struct {
+	path  string
+	lines []string
+}
+
+// NewContextReader creates a context reader.
+func NewContextReader() *ContextReader {
+	return &ContextReader{}
+}
+
+// Lines returns up to count lines before and after the line
+// with the specified 0-based number in the file.
+func (r *ContextReader) Lines(filePath string, lineNumber int, count int) (before []string, after []string, err error) {
+	if r.lines == nil || r.path != filePath {
+		content, err := ioutil.ReadFile(filepath.FromSlash(filePath))
+		if err != nil {
+			return nil, nil, err
+		}
+		r.path = filePath
+		r.lines = strings.Split(string(content), "\n")
+	}
+	if lineNumber >= len(r.lines) {
+		return []string{}, []string{}, nil
+	}
+	before, after = GetSurroundingLines(r.lines, lineNumber, count)
+	return before, after, nil
+}
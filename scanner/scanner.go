@@ -1,8 +1,12 @@
 package scanner
 
 import (
+	"io"
 	"io/ioutil"
+	"regexp"
+	"regexp/syntax"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -15,6 +19,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/inflection"
 	"github.com/jeffijoe/total-rename/lister"
 	"github.com/jeffijoe/total-rename/simplematch"
 	"github.com/mgutz/str"
@@ -30,7 +35,7 @@ const (
 )
 
 // OccurenceGroups is a list of occurence groups.
-// When sorted, files come first.
+// When sorted, files come first, then the deepest paths.
 type OccurenceGroups []*OccurenceGroup
 
 // OccurenceGroup is a grouping of occurences by file path and type.
@@ -44,24 +49,178 @@ type OccurenceGroup struct {
 type Occurences []*Occurence
 
 // Occurence is an occurence of the search text in a file.
+// StartIndex is the rune index of the match in the file contents or path,
+// Offset its byte index, and LineStartIndex its byte index in Line.
+// Casings are all the casings the match is written in, and Casing the
+// one of them it is replaced in. When Override is set, the occurence is
+// replaced with it instead of the replacement in its casing.
 type Occurence struct {
-	Casing                 casing.Casing
-	Match                  string
-	Line                   string
-	StartIndex             int
-	LineStartIndex         int
-	SurroundingLinesBefore []string
-	SurroundingLinesAfter  []string
-	LineNumber             int
-}
-
-// ScanFileNodes will scan files and folders for occurences of the specified string.
-func ScanFileNodes(nodes lister.FileNodes, needle string, binaryPattern string) (OccurenceGroups, error) {
-	binaryIgnore := simplematch.NewMatcher(binaryPattern)
-	variants := casing.GenerateCasings(needle)
+	Needle         string
+	Number         inflection.Number
+	Casing         casing.Casing
+	Casings        casing.Set
+	Match          string
+	Line           string
+	StartIndex     int
+	LineStartIndex int
+	Offset         int
+	LineNumber     int
+	Captures       map[string]string
+	Override       *string
+}
+
+// Needles is a list of needles.
+type Needles []*Needle
+
+// Needle is a string to search for, along with the casing variants
+// it is searched for in. Value is the needle as given, so the plural
+// form of a needle has the same Value as the singular form.
+// When Pattern is set, the needle is searched for using the
+// regular expression instead of the variants. When WordBoundary is set,
+// the needle only matches when it starts and ends on a word boundary.
+// When Mimic is set, the needle is also found in casings none of the
+// variants are in, in the Mimic casing.
+type Needle struct {
+	Value        string
+	Number       inflection.Number
+	Variants     casing.Variants
+	Pattern      *regexp.Regexp
+	WordBoundary bool
+	Mimic        bool
+	mimicPattern *regexp.Regexp
+	skipped      casing.Variants
+}
+
+// NewNeedles creates a needle for each of the specified strings.
+func NewNeedles(values ...string) Needles {
+	result := make(Needles, 0, len(values))
+	for _, v := range values {
+		result = append(result, &Needle{
+			Value:    v,
+			Variants: casing.GenerateCasings(v),
+		})
+	}
+	return result
+}
+
+// NewInflectedNeedles creates a needle for each of the specified strings,
+// as well as for their plural forms.
+func NewInflectedNeedles(rules *inflection.Rules, values ...string) Needles {
+	result := make(Needles, 0, len(values)*2)
+	for _, v := range values {
+		result = append(result, &Needle{
+			Value:    v,
+			Number:   inflection.Singular,
+			Variants: casing.GenerateCasings(v),
+		})
+		plural := rules.Pluralize(v)
+		if plural == v {
+			continue
+		}
+		result = append(result, &Needle{
+			Value:    v,
+			Number:   inflection.Plural,
+			Variants: casing.GenerateCasings(plural),
+		})
+	}
+	return result
+}
+
+// NewRegexpNeedles creates a needle for each of the specified regular expressions.
+// The expressions are case-insensitive unless they opt out with (?-i), so
+// every casing of the needle is found.
+func NewRegexpNeedles(values ...string) (Needles, error) {
+	result := make(Needles, 0, len(values))
+	for _, v := range values {
+		pattern, err := regexp.Compile("(?i)" + v)
+		if err != nil {
+			return nil, err
+		}
+		if !hasLiteralWords(v) {
+			return nil, fmt.Errorf("%s: the text outside of the groups has no letters or digits to determine the casing from", v)
+		}
+		result = append(result, &Needle{
+			Value:   v,
+			Pattern: pattern,
+		})
+	}
+	return result, nil
+}
+
+// hasLiteralWords checks whether the literal text of the expression,
+// outside of its groups, has any letters or digits.
+func hasLiteralWords(expr string) bool {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	var literal func(re *syntax.Regexp) string
+	literal = func(re *syntax.Regexp) string {
+		switch re.Op {
+		case syntax.OpLiteral:
+			return string(re.Rune)
+		case syntax.OpCapture:
+			return ""
+		}
+		result := ""
+		for _, sub := range re.Sub {
+			result = result + literal(sub)
+		}
+		return result
+	}
+	return len(casing.Words(literal(re))) > 0
+}
+
+// RequireWordBoundaries makes every needle match only when it starts and
+// ends on a word boundary, so "space" matches "mySpaceList" and
+// "space_name", but not "workspace" or "spaceship".
+func (needles Needles) RequireWordBoundaries() {
+	for _, n := range needles {
+		n.WordBoundary = true
+	}
+}
+
+// MimicCasings makes every needle also match in any casing, such as
+// "sPaCe", so the replacement can be written in the same letters.
+func (needles Needles) MimicCasings() {
+	for _, n := range needles {
+		n.Mimic = true
+		if n.Pattern == nil {
+			value := n.Variants.GetVariant(casing.Original).Value
+			n.mimicPattern = regexp.MustCompile("(?i)" + regexp.QuoteMeta(value))
+		}
+	}
+}
+
+// SkipCasings stops every needle from searching for its variants in the
+// specified casings. Regular expression needles are not affected.
+func (needles Needles) SkipCasings(casings ...casing.Casing) {
+	skip := map[casing.Casing]bool{}
+	for _, c := range casings {
+		skip[c] = true
+	}
+	for _, n := range needles {
+		variants := casing.Variants{}
+		for _, v := range n.Variants {
+			if skip[v.Casing] {
+				n.skipped = append(n.skipped, v)
+				continue
+			}
+			variants = append(variants, v)
+		}
+		n.Variants = variants
+	}
+}
+
+// ScanFileNodes will scan files and folders for occurences of the specified needles.
+// The contents of files matching binary are not examined, only their path. Neither
+// are the contents of files that look binary, unless they match text; the paths of
+// those files are returned as well so they can be reported.
+func ScanFileNodes(nodes lister.FileNodes, needles Needles, binary *simplematch.Matcher, text *simplematch.Matcher) (OccurenceGroups, []string, error) {
 	type chanResult struct {
-		group *OccurenceGroup
-		err   error
+		group  *OccurenceGroup
+		binary string
+		err    error
 	}
 	ch := make(chan *chanResult, 20)
 	wg := &sync.WaitGroup{}
@@ -70,32 +229,36 @@ func ScanFileNodes(nodes lister.FileNodes, needle string, binaryPattern string)
 		n := node
 		go func() {
 			defer wg.Done()
-			if n.Type == lister.NodeTypeFile && !binaryIgnore.Matches(n.Path) {
-				occurences, err := ScanFile(n.Path, variants)
-				if err != nil {
-					ch <- &chanResult{nil, err}
+			if n.Type == lister.NodeTypeFile && !binary.Matches(n.Path) {
+				scan := ScanFile
+				if text.Matches(n.Path) {
+					scan = ScanTextFile
+				}
+				occurences, err := scan(n.Path, needles)
+				if err == ErrBinaryContent {
+					ch <- &chanResult{binary: filepath.FromSlash(n.Path)}
+				} else if err != nil {
+					ch <- &chanResult{err: err}
 					return
 				}
 				if len(occurences) > 0 {
 					ch <- &chanResult{
-						&OccurenceGroup{
+						group: &OccurenceGroup{
 							Path:       filepath.FromSlash(n.Path),
 							Occurences: occurences,
 							Type:       OccurenceGroupTypeContent,
 						},
-						nil,
 					}
 				}
 			}
-			pathOccurences := ScanFilePath(n.Path, variants)
+			pathOccurences := ScanFilePath(n.Path, needles)
 			if len(pathOccurences) > 0 {
 				ch <- &chanResult{
-					&OccurenceGroup{
+					group: &OccurenceGroup{
 						Path:       filepath.FromSlash(n.Path),
 						Occurences: pathOccurences,
 						Type:       OccurenceGroupTypePath,
 					},
-					nil,
 				}
 			}
 		}()
@@ -107,54 +270,58 @@ func ScanFileNodes(nodes lister.FileNodes, needle string, binaryPattern string)
 	}()
 
 	result := OccurenceGroups{}
+	binaries := []string{}
+	var err error
 	for chanRes := range ch {
-		if chanRes.err != nil {
-			return nil, chanRes.err
+		switch {
+		case chanRes.err != nil:
+			if err == nil {
+				err = chanRes.err
+			}
+		case chanRes.binary != "":
+			binaries = append(binaries, chanRes.binary)
+		default:
+			result = append(result, chanRes.group)
 		}
-		result = append(result, chanRes.group)
+	}
+	if err != nil {
+		return nil, nil, err
 	}
 
 	sort.Stable(result)
-	return result, nil
+	sort.Strings(binaries)
+	return result, binaries, nil
 }
 
 // ScanFilePath scans a file path name for occurences.
-func ScanFilePath(filePath string, variants casing.Variants) Occurences {
+func ScanFilePath(filePath string, needles Needles) Occurences {
 	filePath = filepath.FromSlash(filePath)
-	used := map[int]struct{}{}
-	result := Occurences{}
-	dirLen := utf8.RuneCountInString(filepath.Dir(filePath) + string(os.PathSeparator))
+	dir := filepath.Dir(filePath) + string(os.PathSeparator)
+	dirRunes := utf8.RuneCountInString(dir)
 	fileName := filepath.Base(filePath)
-	for _, variant := range variants {
-		occurences := getOccurences(fileName, variant.Value)
-		if len(occurences) == 0 {
-			continue
-		}
-		for _, startIndex := range occurences {
-			if _, ok := used[startIndex]; ok {
-				continue
-			}
-
-			occurence := &Occurence{
-				Casing:         variant.Casing,
-				Match:          variant.Value,
-				StartIndex:     dirLen + startIndex,
-				LineStartIndex: dirLen + startIndex,
-				Line:           filePath,
-			}
-			used[startIndex] = struct{}{}
-			result = append(result, occurence)
-		}
+	result := findOccurences(fileName, needles)
+	for _, occurence := range result {
+		occurence.StartIndex = dirRunes + occurence.StartIndex
+		occurence.LineStartIndex = len(dir) + occurence.LineStartIndex
+		occurence.Offset = occurence.LineStartIndex
+		occurence.Line = filePath
 	}
-	sort.Sort(result)
 	return result
 }
 
 // ScanFile scans a single file and returns the occurences of the
-// specified variants.
-func ScanFile(filePath string, variants casing.Variants) (Occurences, error) {
-	filePath = filepath.FromSlash(filePath)
-	bytes, err := ioutil.ReadFile(filePath)
+// specified needles. Returns ErrBinaryContent if the file looks binary.
+func ScanFile(filePath string, needles Needles) (Occurences, error) {
+	return scanFile(filePath, needles, true)
+}
+
+// ScanTextFile scans a single file like ScanFile, even if it looks binary.
+func ScanTextFile(filePath string, needles Needles) (Occurences, error) {
+	return scanFile(filePath, needles, false)
+}
+
+func scanFile(filePath string, needles Needles, sniff bool) (Occurences, error) {
+	bytes, err := readFile(filepath.FromSlash(filePath), sniff)
 	if err != nil {
 		return nil, err
 	}
@@ -163,46 +330,56 @@ func ScanFile(filePath string, variants casing.Variants) (Occurences, error) {
 	lines := strings.Split(s, "\n")
 	result := Occurences{}
 	totalIndex := 0
+	totalOffset := 0
 	for lineIdx, line := range lines {
-		used := map[int]struct{}{}
-		for _, variant := range variants {
-			lineOccurences := getOccurences(line, variant.Value)
-			if len(lineOccurences) == 0 {
-				continue
-			}
-
-			for _, startIndex := range lineOccurences {
-				if _, ok := used[startIndex]; ok {
-					continue
-				}
-
-				linesBefore, linesAfter := GetSurroundingLines(lines, lineIdx, 3)
-				occurence := &Occurence{
-					Casing:         variant.Casing,
-					Match:          variant.Value,
-					StartIndex:     totalIndex + startIndex,
-					LineStartIndex: startIndex,
-					Line:           line,
-					SurroundingLinesBefore: linesBefore,
-					SurroundingLinesAfter:  linesAfter,
-					LineNumber:             lineIdx,
-				}
-				used[startIndex] = struct{}{}
-				result = append(result, occurence)
-			}
+		for _, occurence := range findOccurences(line, needles) {
+			occurence.StartIndex = totalIndex + occurence.StartIndex
+			occurence.Offset = totalOffset + occurence.LineStartIndex
+			occurence.Line = line
+			occurence.LineNumber = lineIdx
+			result = append(result, occurence)
 		}
 
 		totalIndex = totalIndex + utf8.RuneCountInString(line) + 1
+		totalOffset = totalOffset + len(line) + 1
 	}
 	sort.Sort(result)
 	return result, nil
 }
 
+// readFile reads the file, but when sniffing only reads the start of
+// it if that is enough to tell that the file is binary.
+func readFile(filePath string, sniff bool) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, SniffLength)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+	if sniff && IsBinary(head) {
+		return nil, ErrBinaryContent
+	}
+	rest, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return append(head, rest...), nil
+}
+
 // GetSurroundingLines returns the surrounding lines
 func GetSurroundingLines(lines []string, lineIdx int, count int) (before []string, after []string) {
 	length := len(lines)
 	before = []string{}
 	after = []string{}
+	if count <= 0 {
+		return before, after
+	}
 
 	for i := lineIdx - count; ; i++ {
 		if i < 0 {
@@ -229,6 +406,179 @@ func GetSurroundingLines(lines []string, lineIdx int, count int) (before []strin
 	return before, after
 }
 
+// findOccurences finds the occurences of every variant of every needle in s.
+// StartIndex is the rune index and LineStartIndex the byte index of each match.
+// When matches overlap, the longest one wins; ties go to the needle and
+// variant that come first.
+func findOccurences(s string, needles Needles) Occurences {
+	candidates := Occurences{}
+	var boundaries map[int]bool
+	for _, needle := range needles {
+		if needle.WordBoundary && boundaries == nil {
+			boundaries = wordBoundaries(s)
+		}
+		if needle.Pattern != nil {
+			for _, oc := range getPatternOccurences(s, needle) {
+				if !needle.WordBoundary || isOnWordBoundaries(boundaries, oc.LineStartIndex, oc.Match) {
+					candidates = append(candidates, oc)
+				}
+			}
+			continue
+		}
+		searched := map[string]bool{}
+		for _, variant := range needle.Variants {
+			// Variants written the same are searched for once,
+			// in every casing they are written in.
+			// Variants of a needle without words, like "-", can be empty.
+			if searched[variant.Value] || variant.Value == "" {
+				continue
+			}
+			searched[variant.Value] = true
+			casings := needle.Variants.Casings(variant.Value)
+			for _, byteIndex := range getOccurences(s, variant.Value) {
+				if needle.WordBoundary && !isOnWordBoundaries(boundaries, byteIndex, variant.Value) {
+					continue
+				}
+				candidates = append(candidates, &Occurence{
+					Needle:         needle.Value,
+					Number:         needle.Number,
+					Casing:         casings.Specific(variant.Value),
+					Casings:        casings,
+					Match:          variant.Value,
+					StartIndex:     utf8.RuneCountInString(s[:byteIndex]),
+					LineStartIndex: byteIndex,
+				})
+			}
+		}
+		if needle.mimicPattern != nil {
+			candidates = append(candidates, getMimicOccurences(s, needle, searched, boundaries)...)
+		}
+	}
+
+	return resolveOverlaps(candidates)
+}
+
+// getMimicOccurences finds the needle in s in the casings its
+// variants are not in, skipping the values that were searched for
+// and the values of the skipped casings.
+func getMimicOccurences(s string, needle *Needle, searched map[string]bool, boundaries map[int]bool) Occurences {
+	result := Occurences{}
+	for _, loc := range needle.mimicPattern.FindAllStringIndex(s, -1) {
+		match := s[loc[0]:loc[1]]
+		if searched[match] || needle.skipped.Casings(match) != 0 || (needle.WordBoundary && !isOnWordBoundaries(boundaries, loc[0], match)) {
+			continue
+		}
+		result = append(result, &Occurence{
+			Needle:         needle.Value,
+			Number:         needle.Number,
+			Casing:         casing.Mimic,
+			Casings:        casing.NewSet(casing.Mimic),
+			Match:          match,
+			StartIndex:     utf8.RuneCountInString(s[:loc[0]]),
+			LineStartIndex: loc[0],
+		})
+	}
+	return result
+}
+
+// resolveOverlaps picks the longest of any overlapping occurences and
+// returns the remaining occurences ordered by StartIndex.
+func resolveOverlaps(candidates Occurences) Occurences {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		left := utf8.RuneCountInString(candidates[i].Match)
+		right := utf8.RuneCountInString(candidates[j].Match)
+		if left != right {
+			return left > right
+		}
+		return candidates[i].StartIndex < candidates[j].StartIndex
+	})
+
+	result := Occurences{}
+	for _, candidate := range candidates {
+		overlaps := false
+		for _, accepted := range result {
+			if candidate.overlaps(accepted) {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			result = append(result, candidate)
+		}
+	}
+	sort.Sort(result)
+	return result
+}
+
+func (o *Occurence) overlaps(other *Occurence) bool {
+	end := o.StartIndex + utf8.RuneCountInString(o.Match)
+	otherEnd := other.StartIndex + utf8.RuneCountInString(other.Match)
+	return o.StartIndex < otherEnd && other.StartIndex < end
+}
+
+// getPatternOccurences finds the matches of a regular expression needle in s.
+// The casing of each match is determined from the matched text, and the
+// captured groups are kept by their number and, if any, their name.
+func getPatternOccurences(s string, needle *Needle) Occurences {
+	result := Occurences{}
+	names := needle.Pattern.SubexpNames()
+	for _, loc := range needle.Pattern.FindAllStringSubmatchIndex(s, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		match := s[loc[0]:loc[1]]
+		captures := map[string]string{}
+		for group := 0; group < len(names); group++ {
+			value := ""
+			if loc[group*2] >= 0 {
+				value = s[loc[group*2]:loc[group*2+1]]
+			}
+			captures[strconv.Itoa(group)] = value
+			if names[group] != "" {
+				captures[names[group]] = value
+			}
+		}
+		literal := literalText(s, loc)
+		casings := casing.NewSet(casing.Original)
+		if literal != "" {
+			casings = casing.DetermineCasings(literal)
+		}
+		if needle.Mimic && casings == casing.NewSet(casing.Original) {
+			casings = casing.NewSet(casing.Mimic)
+		}
+		result = append(result, &Occurence{
+			Needle:         needle.Value,
+			Casing:         casings.Specific(literal),
+			Casings:        casings,
+			Match:          match,
+			StartIndex:     utf8.RuneCountInString(s[:loc[0]]),
+			LineStartIndex: loc[0],
+			Captures:       captures,
+		})
+	}
+	return result
+}
+
+// literalText returns the text of the match at loc without the captured
+// groups, which are kept as they are when replacing, so they do not
+// determine the casing.
+func literalText(s string, loc []int) string {
+	var buf strings.Builder
+	for i := loc[0]; i < loc[1]; i++ {
+		captured := false
+		for group := 2; group+1 < len(loc); group = group + 2 {
+			if loc[group] >= 0 && loc[group] <= i && i < loc[group+1] {
+				captured = true
+				break
+			}
+		}
+		if !captured {
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String()
+}
+
 // Returns a slice of index occurences
 func getOccurences(s string, needle string) []int {
 	buf := []int{}
@@ -244,7 +594,7 @@ func getOccurences(s string, needle string) []int {
 }
 
 func (o Occurence) String() string {
-	return fmt.Sprintf("{ StartIndex: %d, Match: %s, Casing: %d }", o.StartIndex, o.Match, o.Casing)
+	return fmt.Sprintf("{ StartIndex: %d, Match: %s, Needle: %s, Number: %d, Casing: %d }", o.StartIndex, o.Match, o.Needle, o.Number, o.Casing)
 }
 
 func (slice Occurences) Len() int {
@@ -274,7 +624,10 @@ func (slice OccurenceGroups) Less(i int, j int) bool {
 	}
 	leftPathSegmentCount := len(strings.Split(filepath.FromSlash(left.Path), string(os.PathSeparator)))
 	rightPathSegmentCount := len(strings.Split(filepath.FromSlash(right.Path), string(os.PathSeparator)))
-	return leftPathSegmentCount > rightPathSegmentCount
+	if leftPathSegmentCount != rightPathSegmentCount {
+		return leftPathSegmentCount > rightPathSegmentCount
+	}
+	return left.Path < right.Path
 }
 
 func (slice OccurenceGroups) Swap(i int, j int) {
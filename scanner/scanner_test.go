@@ -1,22 +1,28 @@
 package scanner_test
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/inflection"
 	"github.com/jeffijoe/total-rename/lister"
 	"github.com/jeffijoe/total-rename/scanner"
+	"github.com/jeffijoe/total-rename/simplematch"
 	"github.com/jeffijoe/total-rename/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestScanFile(t *testing.T) {
 	test := func(file string, expectedOccurences []scanner.Occurence) {
 		occurences, err := scanner.ScanFile(
 			filepath.Join(util.GetWD(), filepath.FromSlash("../_fixtures"), filepath.FromSlash(file)),
-			casing.GenerateCasings("space"),
+			scanner.NewNeedles("space"),
 		)
 		assert.NoError(t, err)
 		for idx, expected := range expectedOccurences {
@@ -105,7 +111,7 @@ func TestGetSurroundingLines_AlmostStart(t *testing.T) {
 func TestScanFilePath(t *testing.T) {
 	type args struct {
 		filePath string
-		variants casing.Variants
+		needles  scanner.Needles
 	}
 	tests := []struct {
 		name string
@@ -114,7 +120,7 @@ func TestScanFilePath(t *testing.T) {
 	}{
 		{
 			name: "case 1",
-			args: args{filePath: "/test/space-stuff/Space.js", variants: casing.GenerateCasings("space")},
+			args: args{filePath: "/test/space-stuff/Space.js", needles: scanner.NewNeedles("space")},
 			want: scanner.Occurences{
 				&scanner.Occurence{
 					Match:      "Space",
@@ -125,7 +131,7 @@ func TestScanFilePath(t *testing.T) {
 		},
 		{
 			name: "case 2",
-			args: args{filePath: "/test/api/repositories/spaces/SpaceRepository.js", variants: casing.GenerateCasings("space")},
+			args: args{filePath: "/test/api/repositories/spaces/SpaceRepository.js", needles: scanner.NewNeedles("space")},
 			want: scanner.Occurences{
 				&scanner.Occurence{
 					Match:      "Space",
@@ -136,7 +142,7 @@ func TestScanFilePath(t *testing.T) {
 		},
 		{
 			name: "case 3",
-			args: args{filePath: "/test/api/consts/SPACE_TYPE.js", variants: casing.GenerateCasings("Space")},
+			args: args{filePath: "/test/api/consts/SPACE_TYPE.js", needles: scanner.NewNeedles("Space")},
 			want: scanner.Occurences{
 				&scanner.Occurence{
 					Match:      "SPACE",
@@ -147,7 +153,7 @@ func TestScanFilePath(t *testing.T) {
 		},
 		{
 			name: "case 4",
-			args: args{filePath: "/test/api/consts/space-trip", variants: casing.GenerateCasings("Space")},
+			args: args{filePath: "/test/api/consts/space-trip", needles: scanner.NewNeedles("Space")},
 			want: scanner.Occurences{
 				&scanner.Occurence{
 					Match:      "space",
@@ -159,7 +165,7 @@ func TestScanFilePath(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			res := scanner.ScanFilePath(tt.args.filePath, tt.args.variants)
+			res := scanner.ScanFilePath(tt.args.filePath, tt.args.needles)
 			for i, got := range res {
 				expected := tt.want[i]
 				assert.Equal(t, expected.Match, got.Match)
@@ -206,7 +212,7 @@ func TestScanFileNodes(t *testing.T) {
 			},
 		},
 	}
-	result, err := scanner.ScanFileNodes(nodes, "space", "")
+	result, _, err := scanner.ScanFileNodes(nodes, scanner.NewNeedles("space"), simplematch.NewMatcher("", ""), simplematch.NewMatcher("", ""))
 	assert.NoError(t, err)
 	for i, group := range result {
 		exGroup := expectedGroups[i]
@@ -244,7 +250,7 @@ func TestScanFileNodes_IgnoreBinary(t *testing.T) {
 			},
 		},
 	}
-	result, err := scanner.ScanFileNodes(nodes, "space", ".dotfolder")
+	result, _, err := scanner.ScanFileNodes(nodes, scanner.NewNeedles("space"), simplematch.NewMatcher("", ".dotfolder"), simplematch.NewMatcher("", ""))
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(result))
 	for i, group := range result {
@@ -269,7 +275,7 @@ func TestScanFileNodes_Error(t *testing.T) {
 		},
 	}
 
-	_, err := scanner.ScanFileNodes(nodes, "space", "")
+	_, _, err := scanner.ScanFileNodes(nodes, scanner.NewNeedles("space"), simplematch.NewMatcher("", ""), simplematch.NewMatcher("", ""))
 	assert.Error(t, err)
 }
 
@@ -357,3 +363,272 @@ func TestSortingOccurenceGroups_WithContent(t *testing.T) {
 		assert.Equal(t, expectedOrder[i], group.Path)
 	}
 }
+
+func TestScanFilePath_MultipleNeedles(t *testing.T) {
+	res := scanner.ScanFilePath("/test/spaceMember/SpaceMemberOwner.js", scanner.NewNeedles("space", "member", "spaceMember", "owner"))
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, "SpaceMember", res[0].Match)
+	assert.Equal(t, "spaceMember", res[0].Needle)
+	assert.EqualValues(t, casing.TitleCase, res[0].Casing)
+	assert.Equal(t, 18, res[0].StartIndex)
+	assert.Equal(t, "Owner", res[1].Match)
+	assert.Equal(t, "owner", res[1].Needle)
+	assert.Equal(t, 29, res[1].StartIndex)
+}
+
+func TestScanFile_LongestMatchWins(t *testing.T) {
+	occurences, err := scanner.ScanFile(
+		filepath.Join(util.GetWD(), filepath.FromSlash("../_fixtures/fixture3/input/spaceAccessAPI.GET.spec.js")),
+		scanner.NewNeedles("space", "spaceAccess", "access"),
+	)
+	assert.NoError(t, err)
+	spaceAccessCount := 0
+	spaceCaseCount := 0
+	for i, oc := range occurences {
+		if i > 0 {
+			prev := occurences[i-1]
+			assert.True(t, prev.StartIndex+len(prev.Match) <= oc.StartIndex, "occurences must not overlap")
+		}
+		if oc.Needle == "spaceAccess" && oc.Casing == casing.SpaceCase {
+			spaceCaseCount = spaceCaseCount + 1
+			assert.Equal(t, "space access", oc.Match)
+		} else if oc.Needle == "spaceAccess" {
+			spaceAccessCount = spaceAccessCount + 1
+			assert.Equal(t, "SpaceAccess", oc.Match)
+		}
+	}
+	assert.Equal(t, 7, spaceAccessCount)
+	assert.Equal(t, 2, spaceCaseCount)
+}
+
+func TestScanFilePath_Inflected(t *testing.T) {
+	needles := scanner.NewInflectedNeedles(inflection.English(), "category")
+	res := scanner.ScanFilePath("/test/categories/CATEGORIES_Category.js", needles)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, "CATEGORIES", res[0].Match)
+	assert.Equal(t, "category", res[0].Needle)
+	assert.Equal(t, inflection.Plural, res[0].Number)
+	assert.Equal(t, "Category", res[1].Match)
+	assert.Equal(t, inflection.Singular, res[1].Number)
+}
+
+func TestScanFilePath_Regexp(t *testing.T) {
+	needles, err := scanner.NewRegexpNeedles(`v(\d+)space(?P<suffix>id)?`)
+	assert.NoError(t, err)
+	res := scanner.ScanFilePath("/test/v2spaces/V10SpaceId.js", needles)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, "V10SpaceId", res[0].Match)
+	assert.Equal(t, `v(\d+)space(?P<suffix>id)?`, res[0].Needle)
+	assert.EqualValues(t, casing.TitleCase, res[0].Casing)
+	assert.Equal(t, 15, res[0].StartIndex)
+	assert.Equal(t, "10", res[0].Captures["1"])
+	assert.Equal(t, "Id", res[0].Captures["2"])
+	assert.Equal(t, "Id", res[0].Captures["suffix"])
+
+	_, err = scanner.NewRegexpNeedles(`space(`)
+	assert.Error(t, err)
+	_, err = scanner.NewRegexpNeedles(`-+(\w+)`)
+	assert.Error(t, err, "the text outside of the groups has no words")
+}
+
+func TestScanFilePath_NeedleWithoutWords(t *testing.T) {
+	res := scanner.ScanFilePath("/test/space-board", scanner.NewNeedles("-"))
+	require.Equal(t, 1, len(res))
+	assert.Equal(t, "-", res[0].Match)
+}
+
+func TestScanFilePath_RegexpCaptureCasing(t *testing.T) {
+	needles, err := scanner.NewRegexpNeedles(`space(\w+)`)
+	require.NoError(t, err)
+	res := scanner.ScanFilePath("/test/SpaceABC", needles)
+	require.Equal(t, 1, len(res))
+	assert.EqualValues(t, casing.TitleCase, res[0].Casing, "the captured ABC does not count")
+	res = scanner.ScanFilePath("/test/SPACEfoo", needles)
+	require.Equal(t, 1, len(res))
+	assert.EqualValues(t, casing.UpperCase, res[0].Casing, "the captured foo does not count")
+}
+
+func TestScanFilePath_WordBoundary(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     []string
+	}{
+		{fileName: "mySpaceList.js", want: []string{"Space"}},
+		{fileName: "space_name.js", want: []string{"space"}},
+		{fileName: "SPACE-NAME.js", want: []string{"SPACE"}},
+		{fileName: "space.name.js", want: []string{"space"}},
+		{fileName: "v2Space.js", want: []string{"Space"}},
+		{fileName: "space2Ship.js", want: []string{}},
+		{fileName: "HTTPSpaceServer.js", want: []string{"Space"}},
+		{fileName: "SPACEShip.js", want: []string{"SPACE"}},
+		{fileName: "Namespace.js", want: []string{}},
+		{fileName: "workspace.js", want: []string{}},
+		{fileName: "spaceship.js", want: []string{}},
+		{fileName: "MYSPACE.js", want: []string{}},
+		{fileName: "Spaces.js", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			needles := scanner.NewNeedles("space")
+			needles.RequireWordBoundaries()
+			res := scanner.ScanFilePath("/test/"+tt.fileName, needles)
+			got := []string{}
+			for _, oc := range res {
+				got = append(got, oc.Match)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanFilePath_WordBoundaryPlural(t *testing.T) {
+	needles := scanner.NewInflectedNeedles(inflection.English(), "space")
+	needles.RequireWordBoundaries()
+	res := scanner.ScanFilePath("/test/Spaces_workspaces.js", needles)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, "Spaces", res[0].Match)
+	assert.Equal(t, inflection.Plural, res[0].Number)
+}
+
+func TestNeedles_MimicCasings(t *testing.T) {
+	needles := scanner.NewNeedles("space")
+	needles.MimicCasings()
+	res := scanner.ScanFilePath("/test/sPaCe-Space-SPACe", needles)
+	got := []string{}
+	for _, oc := range res {
+		got = append(got, oc.Match+":"+oc.Casing.String())
+	}
+	assert.Equal(t, []string{"sPaCe:mimic", "Space:title", "SPACe:mimic"}, got)
+
+	patterns, err := scanner.NewRegexpNeedles(`v\dspace`)
+	require.NoError(t, err)
+	patterns.MimicCasings()
+	res = scanner.ScanFilePath("/test/V2sPACe", patterns)
+	assert.EqualValues(t, casing.Mimic, res[0].Casing)
+}
+
+func TestNeedles_SkipCasings(t *testing.T) {
+	needles := scanner.NewNeedles("spaceName")
+	needles.SkipCasings(casing.SpaceCase, casing.SentenceCase)
+	res := scanner.ScanFilePath("/test/space.name-Space name-space name-Space-Name", needles)
+	got := []string{}
+	for _, oc := range res {
+		got = append(got, oc.Match+":"+oc.Casing.String())
+	}
+	assert.Equal(t, []string{"space.name:dot", "Space-Name:train"}, got)
+}
+
+func TestNeedles_SkipCasings_Mimic(t *testing.T) {
+	needles := scanner.NewNeedles("space")
+	needles.SkipCasings(casing.UpperCase, casing.UpperSnakeCase, casing.UpperKebabCase)
+	needles.MimicCasings()
+	res := scanner.ScanFilePath("/test/SPACE-sPaCe", needles)
+	got := []string{}
+	for _, oc := range res {
+		got = append(got, oc.Match+":"+oc.Casing.String())
+	}
+	assert.Equal(t, []string{"sPaCe:mimic"}, got, "the skipped casings are not mimicked")
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    bool
+	}{
+		{name: "empty", content: []byte{}, want: false},
+		{name: "text", content: []byte("const space = 'space'\n"), want: false},
+		{name: "utf-8", content: []byte("const espace = 'café ☕'\n"), want: false},
+		{name: "latin-1", content: []byte("const space = 'caf\xe9 au lait, tr\xe8s bien'\n"), want: false},
+		{name: "nul", content: []byte("space\x00space"), want: true},
+		{name: "png", content: []byte("\x89PNG\r\n\x1a\nspace"), want: true},
+		{name: "pdf", content: []byte("%PDF-1.4\nspace"), want: true},
+		{name: "webp", content: []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), want: true},
+		{name: "invalid utf-8", content: []byte("sp\xff\xfe\xfd\xfcace\xfa\xfb"), want: true},
+		{name: "rune cut off at the end", content: append([]byte(strings.Repeat("a", scanner.SniffLength-1)), "☕"...), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, scanner.IsBinary(tt.content))
+		})
+	}
+}
+
+func TestScanFileNodes_DetectBinary(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	write := func(name, content string) *lister.FileNode {
+		p := filepath.Join(tempDir, name)
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+		return &lister.FileNode{Path: p, Type: lister.NodeTypeFile}
+	}
+	nodes := lister.FileNodes{
+		write("space.js", "const space = 1"),
+		write("space.dat", "space\x00space"),
+		write("logo.bin", "\x89PNG\r\n\x1a\nspace"),
+		write("forced.bin", "\x89PNG\r\n\x1a\nspace"),
+	}
+
+	result, binaries, err := scanner.ScanFileNodes(
+		nodes,
+		scanner.NewNeedles("space"),
+		simplematch.NewMatcher(tempDir, ""),
+		simplematch.NewMatcher(tempDir, "forced.*"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tempDir, "logo.bin"), filepath.Join(tempDir, "space.dat")}, binaries)
+	contents := []string{}
+	paths := []string{}
+	for _, group := range result {
+		if group.Type == scanner.OccurenceGroupTypeContent {
+			contents = append(contents, filepath.Base(group.Path))
+		} else {
+			paths = append(paths, filepath.Base(group.Path))
+		}
+	}
+	sort.Strings(contents)
+	sort.Strings(paths)
+	assert.Equal(t, []string{"forced.bin", "space.js"}, contents)
+	assert.Equal(t, []string{"space.dat", "space.js"}, paths)
+}
+
+func TestScanFile_Offset(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	p := filepath.Join(tempDir, "spaces.js")
+	require.NoError(t, ioutil.WriteFile(p, []byte("// héllo\nconst ü = space\n"), 0644))
+
+	occurences, err := scanner.ScanFile(p, scanner.NewNeedles("space"))
+	require.NoError(t, err)
+	require.Len(t, occurences, 1)
+	assert.Equal(t, 19, occurences[0].StartIndex)
+	assert.Equal(t, 21, occurences[0].Offset)
+
+	occurences = scanner.ScanFilePath(p, scanner.NewNeedles("space"))
+	require.Len(t, occurences, 1)
+	assert.Equal(t, len(tempDir)+1, occurences[0].Offset)
+}
+
+func TestContextReader_Lines(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	p := filepath.Join(tempDir, "space.js")
+	require.NoError(t, ioutil.WriteFile(p, []byte("1\n2\n3\nspace\n5\n6"), 0644))
+
+	r := scanner.NewContextReader()
+	before, after, err := r.Lines(p, 3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, before)
+	assert.Equal(t, []string{"5", "6"}, after)
+
+	before, after, err = r.Lines(p, 3, 0)
+	require.NoError(t, err)
+	assert.Empty(t, before)
+	assert.Empty(t, after)
+
+	_, _, err = r.Lines(filepath.Join(tempDir, "missing.js"), 0, 3)
+	assert.Error(t, err)
+}
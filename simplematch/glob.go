@@ -0,0 +1,58 @@
+package simplematch
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GlobToRegexp translates a glob into a regular expression, using the same
+// semantics as .gitignore files: * and ? don't match slashes, ** matches
+// across directories, and [...] is a character class that may be negated with !.
+func GlobToRegexp(glob string) string {
+	var buf strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**") {
+				atStart := i == 0 || glob[i-1] == '/'
+				rest := glob[i+2:]
+				if atStart && strings.HasPrefix(rest, "/") {
+					// **/ matches zero or more directories.
+					buf.WriteString("(?:.*/)?")
+					i = i + 2
+					continue
+				}
+				if atStart && rest == "" {
+					// A trailing /** matches everything inside.
+					buf.WriteString(".*")
+					i = i + 1
+					continue
+				}
+			}
+			buf.WriteString("[^/]*")
+		case '?':
+			buf.WriteString("[^/]")
+		case '[':
+			end := strings.Index(glob[i+1:], "]")
+			if end == -1 {
+				buf.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = i + 1 + end
+		case '\\':
+			if i+1 < len(glob) {
+				i = i + 1
+				buf.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			buf.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return buf.String()
+}
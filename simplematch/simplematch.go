@@ -1,37 +1,138 @@
 package simplematch
 
 import (
+	"path/filepath"
+	"regexp"
 	"strings"
 )
 
+// LegacyPrefix marks a pattern as a case-insensitive substring
+// rather than a glob, which is how every pattern used to work.
+const LegacyPrefix = "contains:"
+
 // Matcher is a matcher.
 type Matcher struct {
-	splat []string
-	empty bool
+	root     string
+	patterns []*pattern
+	empty    bool
 }
 
-// NewMatcher returns a new matcher.
-func NewMatcher(pattern string) *Matcher {
-	splat := strings.Split(pattern, "|")
-	for i, s := range splat {
-		splat[i] = strings.ToUpper(s)
-	}
+// pattern is a single | separated part of a matcher pattern.
+type pattern struct {
+	substring string
+	glob      *regexp.Regexp
+	negate    bool
+	dirOnly   bool
+}
+
+// NewMatcher returns a new matcher for a | separated list of patterns.
+// Patterns are globs anchored to root: a glob with a slash in it (other
+// than a trailing one) matches paths relative to root, otherwise it matches
+// a file or folder name at any depth. A trailing slash only matches folders,
+// and a leading ! excludes paths matched by earlier patterns.
+// Patterns starting with LegacyPrefix match paths containing the rest of the
+// pattern, ignoring case.
+func NewMatcher(root, pattern string) *Matcher {
 	m := &Matcher{
-		splat: splat,
-		empty: len(splat) == 0 || (len(splat) == 1 && splat[0] == ""),
+		root:  root,
+		empty: pattern == "",
+	}
+	if m.empty {
+		return m
+	}
+	for _, s := range strings.Split(pattern, "|") {
+		if p := parsePattern(s); p != nil {
+			m.patterns = append(m.patterns, p)
+		}
 	}
 	return m
 }
 
-// Matches checks if a string matches the pattern.
+func parsePattern(s string) *pattern {
+	if strings.HasPrefix(s, LegacyPrefix) {
+		substring := strings.ToUpper(strings.TrimPrefix(s, LegacyPrefix))
+		if substring == "" {
+			return nil
+		}
+		return &pattern{substring: substring}
+	}
+	p := &pattern{}
+	if strings.HasPrefix(s, "!") {
+		p.negate = true
+		s = s[1:]
+	}
+	if strings.HasSuffix(s, "/") {
+		p.dirOnly = true
+		s = strings.TrimSuffix(s, "/")
+	}
+	if s == "" {
+		return nil
+	}
+	anchored := strings.Contains(s, "/")
+	expr := GlobToRegexp(strings.TrimPrefix(s, "/"))
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	glob, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil
+	}
+	p.glob = glob
+	return p
+}
+
+// Matches checks if a file path matches the pattern.
 func (m *Matcher) Matches(s string) bool {
-	s = strings.ToUpper(s)
+	return m.matches(s, false)
+}
+
+// MatchesDir checks if a folder path matches the pattern.
+func (m *Matcher) MatchesDir(s string) bool {
+	return m.matches(s, true)
+}
+
+func (m *Matcher) matches(s string, isDir bool) bool {
 	if m.empty {
 		return false
 	}
 
-	for _, p := range m.splat {
-		if strings.Contains(s, p) {
+	rel := m.relative(s)
+	result := false
+	for _, p := range m.patterns {
+		if p.negate == !result {
+			// Only patterns that could change the result need checking.
+			continue
+		}
+		if p.matches(s, rel, isDir) {
+			result = !p.negate
+		}
+	}
+	return result
+}
+
+// relative returns the slash separated path of s relative to the root.
+// Paths outside of the root are returned as-is.
+func (m *Matcher) relative(s string) string {
+	if m.root != "" && filepath.IsAbs(s) {
+		rel, err := filepath.Rel(m.root, s)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(s), "/")
+}
+
+// matches checks whether the pattern matches the path or one of its parent folders.
+func (p *pattern) matches(s, rel string, isDir bool) bool {
+	if p.substring != "" {
+		return strings.Contains(strings.ToUpper(s), p.substring)
+	}
+	segments := strings.Split(rel, "/")
+	for i := len(segments); i > 0; i-- {
+		if i == len(segments) && p.dirOnly && !isDir {
+			continue
+		}
+		if p.glob.MatchString(strings.Join(segments[:i], "/")) {
 			return true
 		}
 	}
@@ -12,25 +12,30 @@ func TestNewMatcher(t *testing.T) {
 	tests := []struct {
 		name string
 		args args
-		want *Matcher
+		want []*pattern
 	}{
 		{
 			name: "case 1",
 			args: args{
-				pattern: "a|b|c",
+				pattern: "contains:a|contains:b|contains:c",
 			},
-			want: &Matcher{
-				splat: []string{
-					"A",
-					"B",
-					"C",
-				},
+			want: []*pattern{
+				&pattern{substring: "A"},
+				&pattern{substring: "B"},
+				&pattern{substring: "C"},
 			},
 		},
+		{
+			name: "empty parts",
+			args: args{
+				pattern: "contains:|!|/",
+			},
+			want: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewMatcher(tt.args.pattern); !reflect.DeepEqual(got, tt.want) {
+			if got := NewMatcher("", tt.args.pattern).patterns; !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewMatcher() = %v, want %v", got, tt.want)
 			}
 		})
@@ -39,6 +44,7 @@ func TestNewMatcher(t *testing.T) {
 
 func TestMatcher_Matches(t *testing.T) {
 	type fields struct {
+		root    string
 		pattern string
 	}
 	type args struct {
@@ -52,7 +58,7 @@ func TestMatcher_Matches(t *testing.T) {
 	}{
 		{
 			fields: fields{
-				pattern: "a|b|cool",
+				pattern: "contains:a|contains:b|contains:cool",
 			},
 			args: args{
 				s: "i match cause of a",
@@ -61,7 +67,7 @@ func TestMatcher_Matches(t *testing.T) {
 		},
 		{
 			fields: fields{
-				pattern: "a|b|cool",
+				pattern: "contains:a|contains:b|contains:cool",
 			},
 			args: args{
 				s: "i cool",
@@ -70,7 +76,7 @@ func TestMatcher_Matches(t *testing.T) {
 		},
 		{
 			fields: fields{
-				pattern: "a|b|cool",
+				pattern: "contains:a|contains:b|contains:cool",
 			},
 			args: args{
 				s: "hohoho",
@@ -86,13 +92,110 @@ func TestMatcher_Matches(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name:   "name at any depth",
+			fields: fields{root: "/app", pattern: "dist"},
+			args:   args{s: "/app/src/dist/space.js"},
+			want:   true,
+		},
+		{
+			name:   "name is not a substring",
+			fields: fields{root: "/app", pattern: "dist"},
+			args:   args{s: "/app/src/distance.go"},
+			want:   false,
+		},
+		{
+			name:   "anchored to root",
+			fields: fields{root: "/app", pattern: "/build"},
+			args:   args{s: "/app/build/space.js"},
+			want:   true,
+		},
+		{
+			name:   "anchored to root, nested",
+			fields: fields{root: "/app", pattern: "/build"},
+			args:   args{s: "/app/src/build/space.js"},
+			want:   false,
+		},
+		{
+			name:   "extension",
+			fields: fields{root: "/app", pattern: "*.png|*.woff2"},
+			args:   args{s: "/app/assets/space-logo.png"},
+			want:   true,
+		},
+		{
+			name:   "doublestar",
+			fields: fields{root: "/app", pattern: "src/**/gen/*.js"},
+			args:   args{s: "/app/src/a/b/gen/space.js"},
+			want:   true,
+		},
+		{
+			name:   "doublestar, zero folders",
+			fields: fields{root: "/app", pattern: "src/**/gen/*.js"},
+			args:   args{s: "/app/src/gen/space.js"},
+			want:   true,
+		},
+		{
+			name:   "question mark and character class",
+			fields: fields{root: "/app", pattern: "space-?.[jt]s"},
+			args:   args{s: "/app/space-1.ts"},
+			want:   true,
+		},
+		{
+			name:   "negated character class",
+			fields: fields{root: "/app", pattern: "space-[!0-9].js"},
+			args:   args{s: "/app/space-1.js"},
+			want:   false,
+		},
+		{
+			name:   "negation",
+			fields: fields{root: "/app", pattern: "*.js|!keep.js"},
+			args:   args{s: "/app/src/keep.js"},
+			want:   false,
+		},
+		{
+			name:   "negation, other file",
+			fields: fields{root: "/app", pattern: "*.js|!keep.js"},
+			args:   args{s: "/app/src/space.js"},
+			want:   true,
+		},
+		{
+			name:   "folder only, file",
+			fields: fields{root: "/app", pattern: "space/"},
+			args:   args{s: "/app/src/space"},
+			want:   false,
+		},
+		{
+			name:   "folder only, in folder",
+			fields: fields{root: "/app", pattern: "space/"},
+			args:   args{s: "/app/src/space/index.js"},
+			want:   true,
+		},
+		{
+			name:   "case sensitive",
+			fields: fields{root: "/app", pattern: "*.PNG"},
+			args:   args{s: "/app/space.png"},
+			want:   false,
+		},
+		{
+			name:   "outside root",
+			fields: fields{root: "/app", pattern: "dist"},
+			args:   args{s: "/other/dist/space.js"},
+			want:   true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := NewMatcher(tt.fields.pattern)
+			m := NewMatcher(tt.fields.root, tt.fields.pattern)
 			if got := m.Matches(tt.args.s); got != tt.want {
 				t.Errorf("Matcher.Matches() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestMatcher_MatchesDir(t *testing.T) {
+	m := NewMatcher("/app", "space/")
+	if !m.MatchesDir("/app/src/space") {
+		t.Errorf("Matcher.MatchesDir() = false, want true")
+	}
+}
@@ -0,0 +1,240 @@
+package tui
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeffijoe/total-rename/replacer"
+	"github.com/jeffijoe/total-rename/review"
+	"github.com/jeffijoe/total-rename/scanner"
+)
+
+// entry is a file or folder with occurences in its contents or path.
+type entry struct {
+	path  string
+	items []*review.Item
+}
+
+// row is a line of the file tree, either a folder or an entry.
+type row struct {
+	name  string
+	depth int
+	entry *entry
+}
+
+// model is the state of the reviewer. Occurences are reviewed in the
+// order of the file tree, so moving down never jumps back up the tree.
+type model struct {
+	session      *review.Session
+	replacements replacer.Replacements
+	context      *scanner.ContextReader
+	contextLines int
+	entries      []*entry
+	rows         []*row
+	items        []*review.Item
+	cursor       int
+}
+
+func newModel(session *review.Session, replacements replacer.Replacements, root string, contextLines int) *model {
+	m := &model{
+		session:      session,
+		replacements: replacements,
+		context:      scanner.NewContextReader(),
+		contextLines: contextLines,
+	}
+	byPath := map[string]*entry{}
+	for _, item := range session.Items {
+		path := item.Group.Path
+		if rel, err := filepath.Rel(root, path); err == nil {
+			path = rel
+		}
+		path = filepath.ToSlash(path)
+		e, ok := byPath[path]
+		if !ok {
+			e = &entry{path: path}
+			byPath[path] = e
+			m.entries = append(m.entries, e)
+		}
+		e.items = append(e.items, item)
+	}
+	sort.SliceStable(m.entries, func(i, j int) bool {
+		return lessPath(m.entries[i].path, m.entries[j].path)
+	})
+	for _, e := range m.entries {
+		// Contents first, then the path, like the changes are applied.
+		sort.SliceStable(e.items, func(i, j int) bool {
+			return e.items[i].Group.Type < e.items[j].Group.Type
+		})
+		m.items = append(m.items, e.items...)
+	}
+	m.rows = treeRows(m.entries)
+	return m
+}
+
+// lessPath orders slash separated paths by their segments,
+// so folders come right before their contents.
+func lessPath(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// treeRows lays out the sorted entries as a tree, adding rows for the
+// folders that lead to them and have no occurences themselves.
+func treeRows(entries []*entry) []*row {
+	result := []*row{}
+	shown := map[string]bool{}
+	for _, e := range entries {
+		segments := strings.Split(e.path, "/")
+		for i := 0; i < len(segments)-1; i++ {
+			dir := strings.Join(segments[:i+1], "/")
+			if !shown[dir] {
+				shown[dir] = true
+				result = append(result, &row{name: segments[i] + "/", depth: i})
+			}
+		}
+		shown[e.path] = true
+		result = append(result, &row{name: segments[len(segments)-1], depth: len(segments) - 1, entry: e})
+	}
+	return result
+}
+
+// current returns the occurence under the cursor.
+func (m *model) current() *review.Item {
+	return m.items[m.cursor]
+}
+
+// currentEntry returns the entry of the occurence under the cursor.
+func (m *model) currentEntry() *entry {
+	return m.entryOf(m.cursor)
+}
+
+func (m *model) entryOf(index int) *entry {
+	item := m.items[index]
+	for _, e := range m.entries {
+		for _, other := range e.items {
+			if other == item {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func (m *model) move(delta int) {
+	m.cursor = m.cursor + delta
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.cursor >= len(m.items) {
+		m.cursor = len(m.items) - 1
+	}
+}
+
+// moveEntry moves the cursor to the first occurence of the next
+// or previous entry.
+func (m *model) moveEntry(delta int) {
+	current := m.currentEntry()
+	for i, e := range m.entries {
+		if e != current {
+			continue
+		}
+		next := i + delta
+		if next < 0 || next >= len(m.entries) {
+			return
+		}
+		target := m.entries[next].items[0]
+		for j, item := range m.items {
+			if item == target {
+				m.cursor = j
+				return
+			}
+		}
+	}
+}
+
+// toggle turns the occurence under the cursor on or off.
+func (m *model) toggle() {
+	item := m.current()
+	if item.Decision == review.Accepted {
+		item.Decision = review.Skipped
+	} else {
+		item.Decision = review.Accepted
+	}
+}
+
+// toggleEntry turns every occurence in the current entry on,
+// or off when they all are on already.
+func (m *model) toggleEntry() {
+	items := m.currentEntry().items
+	decision := review.Skipped
+	for _, item := range items {
+		if item.Decision != review.Accepted {
+			decision = review.Accepted
+		}
+	}
+	for _, item := range items {
+		item.Decision = decision
+	}
+}
+
+// selected returns the number of occurences that are on.
+func (m *model) selected() int {
+	result := 0
+	for _, item := range m.items {
+		if item.Decision == review.Accepted {
+			result = result + 1
+		}
+	}
+	return result
+}
+
+// piece is a part of a line drawn in a style.
+type piece struct {
+	text  string
+	style style
+}
+
+// linePieces splits the line of the occurence into the text around
+// the occurences on it. With preview set, the occurences that are on are
+// replaced; otherwise every occurence is highlighted as it is.
+func (m *model) linePieces(item *review.Item, preview bool) []piece {
+	line := item.Occurence.Line
+	same := []*scanner.Occurence{}
+	for _, other := range item.Group.Occurences {
+		if other.Line == line && other.LineNumber == item.Occurence.LineNumber {
+			same = append(same, other)
+		}
+	}
+	sort.Slice(same, func(i, j int) bool {
+		return same[i].LineStartIndex < same[j].LineStartIndex
+	})
+
+	decisions := map[*scanner.Occurence]review.Decision{}
+	for _, other := range m.items {
+		decisions[other.Occurence] = other.Decision
+	}
+	result := []piece{}
+	last := 0
+	for _, oc := range same {
+		if oc.LineStartIndex < last {
+			continue
+		}
+		result = append(result, piece{line[last:oc.LineStartIndex], styleNormal})
+		switch {
+		case !preview:
+			result = append(result, piece{oc.Match, styleMatch})
+		case decisions[oc] == review.Accepted:
+			result = append(result, piece{m.replacements.Replace(oc), styleReplacement})
+		default:
+			result = append(result, piece{oc.Match, styleNormal})
+		}
+		last = oc.LineStartIndex + len(oc.Match)
+	}
+	return append(result, piece{line[last:], styleNormal})
+}
@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jeffijoe/total-rename/review"
+	"github.com/jeffijoe/total-rename/scanner"
+)
+
+// Minimum size of the terminal.
+const (
+	minWidth  = 40
+	minHeight = 10
+)
+
+const footer = " ↑↓ move  ←→ file  space toggle  a toggle file  +- context  enter rename  q quit"
+
+// render draws the reviewer on a screen of the specified size.
+func render(m *model, width, height int) *screen {
+	s := newScreen(width, height)
+	if width < minWidth || height < minHeight {
+		s.put(0, 0, width, "The terminal is too small.", styleNormal)
+		return s
+	}
+	s.put(0, 0, width, fmt.Sprintf(" total-rename  %d of %d occurences selected", m.selected(), len(m.items)), styleBar)
+	s.fill(0, 0, width, styleBar)
+	s.put(0, height-1, width, footer, styleBar)
+	s.fill(0, height-1, width, styleBar)
+
+	treeWidth := width / 3
+	if treeWidth > 40 {
+		treeWidth = 40
+	}
+	for y := 1; y < height-1; y++ {
+		s.put(treeWidth, y, width, "│", styleDim)
+	}
+	renderTree(s, m, 0, 1, treeWidth, height-2)
+	renderOccurence(s, m, treeWidth+2, 1, width, height-2)
+	return s
+}
+
+// renderTree draws the file tree, scrolled to the current entry.
+func renderTree(s *screen, m *model, x, y, maxX, height int) {
+	current := m.currentEntry()
+	selected := 0
+	for i, r := range m.rows {
+		if r.entry == current {
+			selected = i
+		}
+	}
+	top := scrollTop(selected, len(m.rows), height)
+	for i := top; i < len(m.rows) && i < top+height; i++ {
+		r := m.rows[i]
+		lineY := y + i - top
+		indent := strings.Repeat("  ", r.depth)
+		if r.entry == nil {
+			s.put(s.put(x, lineY, maxX, " "+indent, styleNormal), lineY, maxX, r.name, styleDir)
+			continue
+		}
+		text := fmt.Sprintf(" %s%s %s", indent, checkbox(r.entry.items), r.name)
+		st := styleNormal
+		if r.entry == current {
+			st = styleSelected
+		}
+		s.put(x, lineY, maxX, text, st)
+		if r.entry == current {
+			s.fill(x, lineY, maxX, styleSelected)
+		}
+	}
+}
+
+// checkbox shows whether all, some or none of the occurences are on.
+func checkbox(items []*review.Item) string {
+	accepted := 0
+	for _, item := range items {
+		if item.Decision == review.Accepted {
+			accepted = accepted + 1
+		}
+	}
+	switch accepted {
+	case len(items):
+		return "[x]"
+	case 0:
+		return "[ ]"
+	}
+	return "[-]"
+}
+
+// renderOccurence draws the current occurence in context with a preview
+// of its line, followed by the list of occurences in the same entry.
+func renderOccurence(s *screen, m *model, x, y, maxX, height int) {
+	item := m.current()
+	oc := item.Occurence
+	bottom := y + height
+	s.put(x, y, maxX, m.currentEntry().path, style{bold: true})
+	y = y + 1
+	state := "off"
+	if item.Decision == review.Accepted {
+		state = "on"
+	}
+	where := "in path"
+	if item.Group.Type == scanner.OccurenceGroupTypeContent {
+		where = fmt.Sprintf("line %d, column %d", oc.LineNumber+1, column(oc))
+	}
+	s.put(x, y, maxX, fmt.Sprintf("%s, %s %s → %s (%s)", where, m.replacements.Casing(oc), oc.Match, m.replacements.Replace(oc), state), styleDim)
+	y = y + 2
+
+	shift := 0
+	if available := maxX - x - 8; column(oc)+utf8.RuneCountInString(oc.Match) > available {
+		shift = column(oc) - available/3
+	}
+	before, after := []string{}, []string{}
+	if item.Group.Type == scanner.OccurenceGroupTypeContent {
+		// The context is left out when the file can not be read.
+		before, after, _ = m.context.Lines(item.Group.Path, oc.LineNumber, m.contextLines)
+	}
+	for i, line := range before {
+		number := oc.LineNumber + i + 1 - len(before)
+		putLine(s, x, y, maxX, fmt.Sprintf("%6d  ", number), []piece{{line, styleDim}}, shift)
+		y = y + 1
+	}
+	prefix := "  path  "
+	if item.Group.Type == scanner.OccurenceGroupTypeContent {
+		prefix = fmt.Sprintf("%6d  ", oc.LineNumber+1)
+	}
+	putLine(s, x, y, maxX, prefix, m.linePieces(item, false), shift)
+	putLine(s, x, y+1, maxX, "     →  ", m.linePieces(item, true), shift)
+	y = y + 2
+	for i, line := range after {
+		putLine(s, x, y, maxX, fmt.Sprintf("%6d  ", oc.LineNumber+i+2), []piece{{line, styleDim}}, shift)
+		y = y + 1
+	}
+
+	y = y + 1
+	if y >= bottom {
+		return
+	}
+	renderList(s, m, x, y, maxX, bottom-y)
+}
+
+// renderList draws the occurences of the current entry, one per line.
+func renderList(s *screen, m *model, x, y, maxX, height int) {
+	items := m.currentEntry().items
+	current := m.current()
+	selected := 0
+	for i, item := range items {
+		if item == current {
+			selected = i
+		}
+	}
+	top := scrollTop(selected, len(items), height)
+	for i := top; i < len(items) && i < top+height; i++ {
+		item := items[i]
+		oc := item.Occurence
+		mark := "[ ]"
+		if item.Decision == review.Accepted {
+			mark = "[x]"
+		}
+		where := "path"
+		if item.Group.Type == scanner.OccurenceGroupTypeContent {
+			where = fmt.Sprintf("%d:%d", oc.LineNumber+1, column(oc))
+		}
+		text := fmt.Sprintf("%s %-9s %s → %s", mark, where, oc.Match, m.replacements.Replace(oc))
+		lineY := y + i - top
+		if item == current {
+			s.put(x, lineY, maxX, text, styleSelected)
+			s.fill(x, lineY, maxX, styleSelected)
+			continue
+		}
+		s.put(x, lineY, maxX, text, styleNormal)
+	}
+}
+
+// putLine draws a prefix followed by the pieces of a line,
+// leaving out the first shift characters of the line.
+func putLine(s *screen, x, y, maxX int, prefix string, pieces []piece, shift int) {
+	x = s.put(x, y, maxX, prefix, styleDim)
+	if shift > 0 {
+		x = s.put(x, y, maxX, "…", styleDim)
+	}
+	for _, p := range pieces {
+		text := p.text
+		for shift > 0 && text != "" {
+			_, size := utf8.DecodeRuneInString(text)
+			text = text[size:]
+			shift = shift - 1
+		}
+		x = s.put(x, y, maxX, text, p.style)
+	}
+}
+
+// column returns the 1-based column of the occurence in its line.
+func column(oc *scanner.Occurence) int {
+	return utf8.RuneCountInString(oc.Line[:oc.LineStartIndex]) + 1
+}
+
+// scrollTop returns the first of count lines to show in height lines,
+// keeping the selected line in view.
+func scrollTop(selected, count, height int) int {
+	top := selected - height/2
+	if top > count-height {
+		top = count - height
+	}
+	if top < 0 {
+		top = 0
+	}
+	return top
+}
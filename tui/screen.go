@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+)
+
+// style is how a cell is drawn.
+type style struct {
+	fg      int
+	reverse bool
+	bold    bool
+}
+
+// Colors are ANSI foreground color codes, 0 being the default color.
+const (
+	colorDefault = 0
+	colorGray    = 90
+	colorGreen   = 32
+	colorYellow  = 33
+	colorCyan    = 36
+)
+
+var (
+	styleNormal      = style{}
+	styleDim         = style{fg: colorGray}
+	styleMatch       = style{fg: colorYellow, bold: true}
+	styleReplacement = style{fg: colorGreen, bold: true}
+	styleDir         = style{fg: colorCyan}
+	styleBar         = style{reverse: true}
+	styleSelected    = style{reverse: true}
+)
+
+type cell struct {
+	r     rune
+	style style
+}
+
+// screen is a buffer of what is drawn on the terminal,
+// so a frame is written in one go and never wraps.
+type screen struct {
+	width  int
+	height int
+	cells  [][]cell
+}
+
+func newScreen(width, height int) *screen {
+	s := &screen{width: width, height: height, cells: make([][]cell, height)}
+	for y := range s.cells {
+		s.cells[y] = make([]cell, width)
+		for x := range s.cells[y] {
+			s.cells[y][x] = cell{' ', styleNormal}
+		}
+	}
+	return s
+}
+
+// put draws the text at x, y, cutting it off at maxX. Tabs are expanded
+// and other control characters are left out. Returns the x after the text.
+func (s *screen) put(x, y, maxX int, text string, st style) int {
+	if y < 0 || y >= s.height {
+		return x
+	}
+	if maxX > s.width {
+		maxX = s.width
+	}
+	for _, r := range text {
+		switch {
+		case r == '\t':
+			for i := 0; i < 4; i++ {
+				x = s.set(x, y, maxX, ' ', st)
+			}
+		case r < ' ' || r == 0x7f:
+		default:
+			x = s.set(x, y, maxX, r, st)
+		}
+	}
+	return x
+}
+
+func (s *screen) set(x, y, maxX int, r rune, st style) int {
+	if x >= 0 && x < maxX {
+		s.cells[y][x] = cell{r, st}
+	}
+	return x + 1
+}
+
+// fill sets the style of the cells from x to maxX on line y.
+func (s *screen) fill(x, y, maxX int, st style) {
+	if y < 0 || y >= s.height {
+		return
+	}
+	for ; x < maxX && x < s.width; x++ {
+		s.cells[y][x].style = st
+	}
+}
+
+// line returns the text on line y, without styles.
+func (s *screen) line(y int) string {
+	var b strings.Builder
+	for _, c := range s.cells[y] {
+		b.WriteRune(c.r)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// String returns the escape sequences drawing the screen from the top left.
+func (s *screen) String() string {
+	var b strings.Builder
+	b.WriteString("\033[H")
+	for y, row := range s.cells {
+		current := style{fg: -1}
+		for _, c := range row {
+			if c.style != current {
+				b.WriteString(c.style.sgr())
+				current = c.style
+			}
+			b.WriteRune(c.r)
+		}
+		b.WriteString("\033[0m")
+		if y < s.height-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	return b.String()
+}
+
+// sgr returns the escape sequence switching to the style.
+func (st style) sgr() string {
+	codes := []string{"0"}
+	if st.bold {
+		codes = append(codes, "1")
+	}
+	if st.reverse {
+		codes = append(codes, "7")
+	}
+	if st.fg != colorDefault {
+		codes = append(codes, strconv.Itoa(st.fg))
+	}
+	return "\033[" + strings.Join(codes, ";") + "m"
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Keys that are not a single character.
+const (
+	keyNone = rune(-iota - 1)
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyEscape
+	keyInterrupt
+)
+
+var escapeSequences = map[string]rune{
+	"\x1b[A":  keyUp,
+	"\x1bOA":  keyUp,
+	"\x1b[B":  keyDown,
+	"\x1bOB":  keyDown,
+	"\x1b[C":  keyRight,
+	"\x1bOC":  keyRight,
+	"\x1b[D":  keyLeft,
+	"\x1bOD":  keyLeft,
+	"\x1b[5~": keyPageUp,
+	"\x1b[6~": keyPageDown,
+	"\x1b":    keyEscape,
+}
+
+// terminal is the terminal in raw mode, showing the alternate screen.
+type terminal struct {
+	in    *os.File
+	out   *os.File
+	state *term.State
+}
+
+// openTerminal switches to raw mode and the alternate screen.
+func openTerminal() (*terminal, error) {
+	t := &terminal{in: os.Stdin, out: os.Stdout}
+	state, err := term.MakeRaw(int(t.in.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	t.state = state
+	enableVirtualTerminal(t.in, t.out)
+	t.out.WriteString("\033[?1049h\033[?25l")
+	return t, nil
+}
+
+// IsTerminal checks whether stdin and stdout are a terminal.
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// close restores the screen and the mode the terminal was in.
+func (t *terminal) close() error {
+	t.out.WriteString("\033[?25h\033[?1049l")
+	return term.Restore(int(t.in.Fd()), t.state)
+}
+
+func (t *terminal) size() (int, int, error) {
+	return term.GetSize(int(t.out.Fd()))
+}
+
+func (t *terminal) draw(s *screen) error {
+	_, err := t.out.WriteString(s.String())
+	return err
+}
+
+// readKey reads a key press, translating escape sequences.
+func (t *terminal) readKey() (rune, error) {
+	buf := make([]byte, 16)
+	n, err := t.in.Read(buf)
+	if err != nil {
+		return keyNone, err
+	}
+	// A character of several bytes can be split over reads.
+	for n < len(buf) && buf[0] >= utf8.RuneSelf && !utf8.FullRune(buf[:n]) {
+		m, err := t.in.Read(buf[n:])
+		if err != nil {
+			return keyNone, err
+		}
+		n = n + m
+	}
+	return parseKey(buf[:n]), nil
+}
+
+func parseKey(b []byte) rune {
+	if key, ok := escapeSequences[string(b)]; ok {
+		return key
+	}
+	switch {
+	case len(b) == 0 || b[0] == 0x1b:
+		return keyNone
+	case b[0] == 3:
+		return keyInterrupt
+	case b[0] == '\r':
+		return '\n'
+	}
+	r, _ := utf8.DecodeRune(b)
+	if r == utf8.RuneError {
+		return keyNone
+	}
+	return r
+}
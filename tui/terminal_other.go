@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package tui
+
+import "os"
+
+// enableVirtualTerminal is a no-op, terminals interpret escape sequences.
+func enableVirtualTerminal(in, out *os.File) {}
@@ -0,0 +1,25 @@
+//go:build windows
+// +build windows
+
+package tui
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal makes the console interpret escape sequences
+// written to out, and send the arrow and page keys to in as them.
+func enableVirtualTerminal(in, out *os.File) {
+	addConsoleMode(in, windows.ENABLE_VIRTUAL_TERMINAL_INPUT)
+	addConsoleMode(out, windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+}
+
+func addConsoleMode(f *os.File, flags uint32) {
+	var mode uint32
+	handle := windows.Handle(f.Fd())
+	if err := windows.GetConsoleMode(handle, &mode); err == nil {
+		windows.SetConsoleMode(handle, mode|flags)
+	}
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"errors"
+
+	"github.com/jeffijoe/total-rename/replacer"
+	"github.com/jeffijoe/total-rename/review"
+	"github.com/jeffijoe/total-rename/scanner"
+)
+
+// ErrAborted is returned by Review when the review was quit
+// without renaming anything.
+var ErrAborted = errors.New("aborted")
+
+// contextStep is the number of lines + and - add or remove.
+const contextStep = 5
+
+// Review shows the occurences in a full-screen reviewer, with the files
+// on the left and the current occurence with contextLines lines around it
+// on the right. Every occurence starts out on and can be turned off.
+// Returns the groups with the occurences that are on when Enter is
+// pressed, or ErrAborted.
+func Review(groups scanner.OccurenceGroups, replacements replacer.Replacements, root string, contextLines int) (scanner.OccurenceGroups, error) {
+	session := review.NewSession(groups)
+	if len(session.Items) == 0 {
+		return scanner.OccurenceGroups{}, nil
+	}
+	for _, item := range session.Items {
+		item.Decision = review.Accepted
+	}
+	m := newModel(session, replacements, root, contextLines)
+
+	t, err := openTerminal()
+	if err != nil {
+		return nil, err
+	}
+	defer t.close()
+	for {
+		width, height, err := t.size()
+		if err != nil {
+			return nil, err
+		}
+		if err := t.draw(render(m, width, height)); err != nil {
+			return nil, err
+		}
+		key, err := t.readKey()
+		if err != nil {
+			return nil, err
+		}
+		done, err := m.handle(key, height)
+		if done || err != nil {
+			return session.Groups(), err
+		}
+	}
+}
+
+// handle handles a key press, returning true when the review is done.
+func (m *model) handle(key rune, height int) (bool, error) {
+	switch key {
+	case keyUp, 'k':
+		m.move(-1)
+	case keyDown, 'j':
+		m.move(1)
+	case keyPageUp:
+		m.move(-height / 2)
+	case keyPageDown:
+		m.move(height / 2)
+	case keyLeft, 'h':
+		m.moveEntry(-1)
+	case keyRight, 'l':
+		m.moveEntry(1)
+	case ' ':
+		m.toggle()
+	case 'a':
+		m.toggleEntry()
+	case '+':
+		m.contextLines = m.contextLines + contextStep
+	case '-':
+		m.contextLines = m.contextLines - contextStep
+		if m.contextLines < 0 {
+			m.contextLines = 0
+		}
+	case '\n':
+		return true, nil
+	case 'q', keyEscape, keyInterrupt:
+		return true, ErrAborted
+	}
+	return false, nil
+}
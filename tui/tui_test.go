@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jeffijoe/total-rename/casing"
+	"github.com/jeffijoe/total-rename/mapping"
+	"github.com/jeffijoe/total-rename/replacer"
+	"github.com/jeffijoe/total-rename/review"
+	"github.com/jeffijoe/total-rename/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pathGroup(elem ...string) *scanner.OccurenceGroup {
+	path := filepath.Join(elem...)
+	return &scanner.OccurenceGroup{
+		Path:       path,
+		Type:       scanner.OccurenceGroupTypePath,
+		Occurences: scanner.ScanFilePath(path, scanner.NewNeedles("space")),
+	}
+}
+
+// testModel creates a model of occurences in a temporary folder,
+// which the returned function removes.
+func testModel(t *testing.T) (*model, func()) {
+	root, err := ioutil.TempDir("", "total-rename")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "spaces"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "spaces", "space.js"), []byte("// spaces\nconst space = new Space()\nexport default space\n"), 0644))
+	groups := scanner.OccurenceGroups{
+		&scanner.OccurenceGroup{
+			Path: filepath.Join(root, "spaces", "space.js"),
+			Type: scanner.OccurenceGroupTypeContent,
+			Occurences: scanner.Occurences{
+				&scanner.Occurence{Needle: "space", Casing: casing.Original, Match: "space", Line: "const space = new Space()", LineStartIndex: 6, LineNumber: 1},
+				&scanner.Occurence{Needle: "space", Casing: casing.TitleCase, Match: "Space", Line: "const space = new Space()", LineStartIndex: 18, LineNumber: 1},
+			},
+		},
+		pathGroup(root, "spaces", "space.js"),
+		pathGroup(root, "spaces"),
+		pathGroup(root, "lib", "util", "space.go"),
+	}
+	session := review.NewSession(groups)
+	for _, item := range session.Items {
+		item.Decision = review.Accepted
+	}
+	replacements := replacer.NewReplacements(mapping.Pairs{mapping.Pair{Needle: "space", Replacement: "board"}})
+	return newModel(session, replacements, root, 1), func() { os.RemoveAll(root) }
+}
+
+func TestNewModel_Tree(t *testing.T) {
+	m, cleanup := testModel(t)
+	defer cleanup()
+	rows := []string{}
+	for _, r := range m.rows {
+		rows = append(rows, r.name)
+	}
+	assert.Equal(t, []string{"lib/", "util/", "space.go", "spaces", "space.js"}, rows)
+	assert.Equal(t, []int{0, 1, 2, 0, 1}, []int{m.rows[0].depth, m.rows[1].depth, m.rows[2].depth, m.rows[3].depth, m.rows[4].depth})
+
+	matches := []string{}
+	for _, item := range m.items {
+		matches = append(matches, item.Occurence.Line[item.Occurence.LineStartIndex:])
+	}
+	assert.Equal(t, []string{"space.go", "spaces", "space = new Space()", "Space()", "space.js"}, matches, "occurences follow the tree, contents before paths")
+}
+
+func TestModel_Handle(t *testing.T) {
+	m, cleanup := testModel(t)
+	defer cleanup()
+	keys := []rune{keyDown, keyDown, ' ', keyDown, ' ', keyUp, 'a', keyLeft, 'a'}
+	for _, key := range keys {
+		done, err := m.handle(key, 20)
+		require.NoError(t, err)
+		require.False(t, done)
+	}
+	done, err := m.handle('\n', 20)
+	assert.True(t, done)
+	assert.NoError(t, err)
+
+	paths := []string{}
+	for _, g := range m.session.Groups() {
+		for _, oc := range g.Occurences {
+			paths = append(paths, filepath.Base(g.Path)+":"+oc.Line[oc.LineStartIndex:])
+		}
+	}
+	assert.Equal(t, []string{
+		"space.js:space = new Space()",
+		"space.js:Space()",
+		"space.js:space.js",
+		"space.go:space.go",
+	}, paths)
+
+	done, err = m.handle('q', 20)
+	assert.True(t, done)
+	assert.Equal(t, ErrAborted, err)
+}
+
+func TestRender(t *testing.T) {
+	m, cleanup := testModel(t)
+	defer cleanup()
+	m.handle(keyDown, 20)
+	m.handle(keyDown, 20)
+	m.handle(keyDown, 20)
+	m.handle(' ', 20)
+	s := render(m, 80, 16)
+
+	expected := []string{
+		" total-rename  4 of 5 occurences selected",
+		" lib/                     │ spaces/space.js",
+		"   util/                  │ line 2, column 19, title Space → Board (off)",
+		"     [x] space.go         │",
+		" [x] spaces               │      1  // spaces",
+		"   [-] space.js           │      2  const space = new Space()",
+		"                          │      →  const board = new Space()",
+		"                          │      3  export default space",
+		"                          │",
+		"                          │ [x] 2:7       space → board",
+		"                          │ [ ] 2:19      Space → Board",
+		"                          │ [x] path      space → board",
+	}
+	for y, line := range expected {
+		assert.Equal(t, line, s.line(y))
+	}
+	assert.Equal(t, footer, s.line(15))
+}
+
+func TestRender_TooSmall(t *testing.T) {
+	m, cleanup := testModel(t)
+	defer cleanup()
+	s := render(m, 20, 5)
+	assert.Equal(t, "The terminal is too", s.line(0))
+}
+
+func TestParseKey(t *testing.T) {
+	assert.Equal(t, keyUp, parseKey([]byte("\x1b[A")))
+	assert.Equal(t, keyEscape, parseKey([]byte("\x1b")))
+	assert.Equal(t, keyNone, parseKey([]byte("\x1b[1;5A")))
+	assert.Equal(t, '\n', parseKey([]byte("\r")))
+	assert.Equal(t, keyInterrupt, parseKey([]byte{3}))
+	assert.Equal(t, 'a', parseKey([]byte("a")))
+	assert.Equal(t, 'é', parseKey([]byte("é")))
+	assert.Equal(t, keyNone, parseKey([]byte("é")[:1]))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeffijoe/total-rename/journal"
+	"github.com/jeffijoe/total-rename/replacer"
+)
+
+// keptJournals is the number of runs that can be undone. The journals
+// of older runs are removed.
+const keptJournals = 20
+
+// autosaveJournal saves the journal before a run changes anything, and
+// after every change it makes.
+func autosaveJournal(j *journal.Journal) {
+	dir, err := journal.Dir()
+	if err == nil {
+		err = j.Autosave(dir)
+	}
+	if err != nil {
+		fmt.Printf("Could not save the undo journal: %v\n", err)
+	}
+}
+
+// saveJournal saves the journal of a run so it can be undone,
+// removing the journals of older runs.
+func saveJournal(j *journal.Journal) bool {
+	dir, err := journal.Dir()
+	if err == nil {
+		_, err = j.Save(dir)
+	}
+	if err != nil {
+		fmt.Printf("Could not save the undo journal: %v\n", err)
+		return false
+	}
+	if err := journal.Prune(dir, keptJournals); err != nil {
+		fmt.Printf("Could not remove old undo journals: %v\n", err)
+	}
+	return true
+}
+
+// discardJournal removes the journal of a run that left nothing to undo.
+func discardJournal(j *journal.Journal) {
+	dir, err := journal.Dir()
+	if err == nil {
+		err = j.Remove(dir)
+	}
+	if err != nil {
+		fmt.Printf("Could not remove the undo journal: %v\n", err)
+	}
+}
+
+// undo runs the undo subcommand, which reverts the latest run,
+// or the run with the specified journal ID.
+func undo(args []string) {
+	flags := flag.NewFlagSet("undo", flag.ExitOnError)
+	list := flags.Bool("list", false, "Lists the runs that can be undone")
+	flags.Parse(args)
+
+	dir, err := journal.Dir()
+	if err != nil {
+		fmt.Printf("Could not find the undo journals: %v\n", err)
+		return
+	}
+	paths, err := journal.List(dir)
+	if err != nil {
+		fmt.Printf("Could not read the undo journals: %v\n", err)
+		return
+	}
+	if *list {
+		listJournals(paths)
+		return
+	}
+
+	var journalPath string
+	if flags.NArg() > 0 {
+		journalPath = filepath.Join(dir, flags.Arg(0)+".json")
+	} else if len(paths) > 0 {
+		journalPath = paths[len(paths)-1]
+	} else {
+		fmt.Println("Nothing to undo.")
+		return
+	}
+
+	j, err := journal.Load(journalPath)
+	if err != nil {
+		fmt.Printf("Could not read the undo journal: %v\n", err)
+		return
+	}
+	count := len(j.Entries)
+	err = j.Undo(os.Rename, replacer.ReplaceFileContent)
+	if changed, ok := err.(*journal.ChangedError); ok {
+		fmt.Println("Refusing to undo, these files changed since:")
+		for _, p := range changed.Paths {
+			fmt.Println("    " + p)
+		}
+		return
+	}
+	if err != nil {
+		if _, saveErr := j.Save(dir); saveErr != nil {
+			fmt.Printf("Could not save the undo journal: %v\n", saveErr)
+		}
+		fmt.Printf("Undo failed after reverting %d of %d changes: %v\n", count-len(j.Entries), count, err)
+		return
+	}
+	if err := os.Remove(journalPath); err != nil {
+		fmt.Printf("Could not remove the undo journal: %v\n", err)
+	}
+	fmt.Printf("Done! Reverted %d changes of total-rename %s\n", count, strings.Join(j.Args, " "))
+}
+
+func listJournals(paths []string) {
+	if len(paths) == 0 {
+		fmt.Println("Nothing to undo.")
+		return
+	}
+	for i := len(paths) - 1; i >= 0; i-- {
+		j, err := journal.Load(paths[i])
+		if err != nil {
+			fmt.Printf("%s: %v\n", paths[i], err)
+			continue
+		}
+		fmt.Printf("%s  %d changes in %s: total-rename %s\n", j.ID, len(j.Entries), j.WorkingDir, strings.Join(j.Args, " "))
+	}
+}
@@ -74,3 +74,16 @@ func CopyFile(source string, dest string) (err error) {
 
 	return err
 }
+
+// RebasePath moves path from inside of oldBase to inside of newBase.
+// Returns false when path is neither oldBase nor inside of it.
+func RebasePath(path, oldBase, newBase string) (string, bool) {
+	if path == oldBase {
+		return newBase, true
+	}
+	prefix := strings.TrimSuffix(oldBase, string(os.PathSeparator)) + string(os.PathSeparator)
+	if !strings.HasPrefix(path, prefix) {
+		return path, false
+	}
+	return filepath.Join(newBase, strings.TrimPrefix(path, prefix)), true
+}